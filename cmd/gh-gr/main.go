@@ -11,24 +11,31 @@ Usage:
 
 Available Commands:
 
-	cleanup     Clean up untracked local repositories
-	completion  Generate the autocompletion script for the specified shell
-	export      Export current configuration to stdout
-	help        Help about any command
-	import      Import configuration from stdin or a file
-	init        Initialize repository mirror
-	pull        Pull all repositories
-	push        Push all repositories
-	remove      Remove current configuration
-	status      Show status for all repositories
-	update      Update configuration
-	version     Display version information
-	view        Display current configuration
+	cache         Manage the cache of API responses
+	cleanup       Clean up untracked local repositories
+	completion    Generate the autocompletion script for the specified shell
+	exec          Execute a shell command in all repositories
+	export        Export current configuration to stdout
+	fetch         Fetch all repositories
+	help          Help about any command
+	import        Import configuration from stdin or a file
+	init          Initialize repository mirror
+	pull          Pull all repositories
+	push          Push all repositories
+	rate-limit    Display API quotas of configured hosts
+	remote        Manage remotes of all repositories
+	remove        Remove current configuration
+	status        Show status for all repositories
+	sync-upstream Synchronize forks with their parent repositories
+	update        Update configuration
+	version       Display version information
+	view          Display current configuration
 
 Flags:
 
 	-c, --concurrency uint   Concurrency for concurrent jobs (default 12)
 	-h, --help               help for gr
+	    --no-cache           Bypass the cache of API responses
 	-t, --timeout duration   Set timeout for long running jobs (default 10m0s)
 
 Use "gr [command] --help" for more information about a command.
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	cobra "github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = func() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of API responses",
+		Long: "Manage the cache of API responses.\n\n" +
+			"Responses of the GitHub API are cached in the config directory of GitHub CLI " +
+			"and revalidated using conditional requests, which do not count against the rate limit.\n" +
+			"The least recently used responses are evicted, once the cache exceeds 100 MiB.\n" +
+			"The cache can be bypassed with the \"--no-cache\" flag.",
+		Example: "gh gr cache clear",
+		Args:    cobra.NoArgs,
+	}
+
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	return cacheCmd
+}()
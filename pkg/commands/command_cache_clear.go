@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"fmt"
+
+	color "github.com/fatih/color"
+	restclient "github.com/sarumaj/gh-gr/v2/pkg/restclient"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	supererrors "github.com/sarumaj/go-super/errors"
+	cobra "github.com/spf13/cobra"
+)
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:     "clear",
+	Short:   "Remove all cached API responses",
+	Example: "gh gr cache clear",
+	Args:    cobra.NoArgs,
+	Run: func(*cobra.Command, []string) {
+		entries, size, err := restclient.ClearCache()
+		supererrors.Except(err)
+
+		c := util.Console()
+		_ = supererrors.ExceptFn(supererrors.W(
+			fmt.Fprintln(c.Stdout(), c.CheckColors(color.GreenString, "Successfully removed %d cached responses (%s).", entries, util.IntToSizeBytes(int(size), 1024, 3))),
+		))
+	},
+}
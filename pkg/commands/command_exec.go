@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	cobra "github.com/spf13/cobra"
+	pool "gopkg.in/go-playground/pool.v3"
+)
+
+// execFlags represents the flags for exec command
+var execFlags struct {
+	filters []string
+	stream  bool
+}
+
+// execCmd represents the exec command
+var execCmd = func() *cobra.Command {
+	execCmd := &cobra.Command{
+		Use:     "exec [flags] [--] command [args...]",
+		Aliases: []string{"run"},
+		Short:   "Execute a shell command in all repositories",
+		Long: "Execute a shell command in all repositories.\n\n" +
+			"The command is executed concurrently using the system shell with the local repository as working directory.\n" +
+			"The exit code and the captured output are listed for each repository.\n" +
+			"Alternatively, the output can be streamed with each line prefixed by the repository name.\n" +
+			"Supports filtering local repositories using glob match (see \"gr view --help\").",
+		Example: "gh gr exec --match \"*/gh-*\" -- go mod tidy",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			headers := []string{"Repository", "Exit code", "Stdout", "Stderr"}
+			if execFlags.stream {
+				headers = headers[:2]
+			}
+
+			operationLoop[configfile.Repository](execOperation, "Execute", operationContextMap{
+				"command": strings.Join(args, " "),
+				"filters": execFlags.filters,
+				"stream":  execFlags.stream,
+				"mutex":   &sync.Mutex{},
+				"headers": headers,
+			})
+		},
+	}
+
+	flags := execCmd.Flags()
+	flags.SetInterspersed(false)
+	flags.StringArrayVarP(&execFlags.filters, "match", "m", []string{}, "Glob pattern(s) to filter repositories")
+	flags.BoolVarP(&execFlags.stream, "stream", "s", false, "Stream output of the command prefixed with the repository name")
+
+	return execCmd
+}()
+
+// Output of an executed command.
+// It is rendered without color codes in the status table.
+type execOutput string
+
+// Execute shell command in local repository.
+func execOperation(_ pool.WorkUnit, args operationContext) {
+	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
+	repo := unwrapOperationContext[configfile.Repository](args, "object")
+	status := unwrapOperationContext[*operationStatus](args, "status")
+	command := unwrapOperationContext[string](args, "command")
+	stream := unwrapOperationContext[bool](args, "stream")
+	mutex := unwrapOperationContext[*sync.Mutex](args, "mutex")
+
+	logger := loggerEntry.WithField("command", "exec").WithField("repository", repo.Directory)
+
+	directory := filepath.Join(conf.AbsoluteDirectoryPath, repo.Directory)
+	if !util.PathExists(directory) {
+		logger.Debug("Local repository does not exist")
+		status.appendRow(repo.Directory, fmt.Errorf("absent"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(args.Context, conf.Timeout)
+	defer cancel()
+
+	cmd := newShellCommand(ctx, command)
+	cmd.Dir = directory
+
+	var stdout, stderr bytes.Buffer
+	if stream {
+		c := util.Console()
+		prefix := fmt.Sprintf("[%s] ", repo.Directory)
+		outWriter := util.NewPrefixedWriter(c.Stdout(), prefix, mutex)
+		errWriter := util.NewPrefixedWriter(c.Stderr(), prefix, mutex)
+
+		defer func() {
+			_ = outWriter.Flush()
+			_ = errWriter.Flush()
+		}()
+
+		cmd.Stdout, cmd.Stderr = outWriter, errWriter
+
+	} else {
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	}
+
+	logger.Debugf("Executing: %s", command)
+	var exitError *exec.ExitError
+	switch err := cmd.Run(); {
+
+	case ctx.Err() != nil:
+		logger.Debugf("Execution interrupted: %v", ctx.Err())
+		status.appendRow(repo.Directory, ctx.Err())
+		return
+
+	case errors.As(err, &exitError):
+		logger.Debugf("Execution failed: %v", err)
+		if stream {
+			status.appendRow(repo.Directory, fmt.Errorf("%d", exitError.ExitCode()))
+		} else {
+			status.appendRow(repo.Directory, fmt.Errorf("%d", exitError.ExitCode()), squashOutput(stdout), squashOutput(stderr))
+		}
+
+	case err != nil:
+		logger.Debugf("Failed to execute: %v", err)
+		status.appendRow(repo.Directory, err)
+
+	case stream:
+		status.appendRow(repo.Directory, "0")
+
+	default:
+		status.appendRow(repo.Directory, "0", squashOutput(stdout), squashOutput(stderr))
+
+	}
+}
+
+// newShellCommand prepares command line to be executed by the system shell.
+func newShellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		shell := os.Getenv("COMSPEC")
+		if shell == "" {
+			shell = "cmd.exe"
+		}
+
+		return exec.CommandContext(ctx, shell, "/C", command)
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	return exec.CommandContext(ctx, shell, "-c", command)
+}
+
+// squashOutput joins the lines of the captured output so that it fits into a single table cell.
+func squashOutput(buffer bytes.Buffer) execOutput {
+	var lines []string
+	for _, line := range strings.Split(buffer.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return execOutput(strings.Join(lines, "; "))
+}
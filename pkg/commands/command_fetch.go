@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	git "github.com/go-git/go-git/v5"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	cobra "github.com/spf13/cobra"
+	pool "gopkg.in/go-playground/pool.v3"
+)
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetch all repositories",
+	Long: "Fetch all repositories.\n\n" +
+		"Only remote-tracking references of \"origin\" (and \"upstream\" for forks) are updated.\n" +
+		"Neither local branches nor working trees are modified, hence dirty repositories are fetched as well.\n" +
+		"The number of new commits is listed for each remote.",
+	Example: "gh gr fetch",
+	Run: func(*cobra.Command, []string) {
+		operationLoop[configfile.Repository](fetchOperation, "Fetch", operationContextMap{
+			"headers": []string{"Repository", "Status", "Origin", "Upstream"},
+		})
+	},
+}
+
+// Fetch remote-tracking references of local repository.
+func fetchOperation(_ pool.WorkUnit, args operationContext) {
+	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
+	repo := unwrapOperationContext[configfile.Repository](args, "object")
+	status := unwrapOperationContext[*operationStatus](args, "status")
+
+	logger := loggerEntry.WithField("command", "fetch").WithField("repository", repo.Directory)
+
+	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
+
+	if !util.PathExists(repo.Directory) {
+		logger.Debug("Local repository does not exist")
+		status.appendRow(repo.Directory, fmt.Errorf("absent"))
+		return
+	}
+
+	repository, err := openRepository(repo, status)
+	if err != nil {
+		logger.Debugf("Failed to open: %v", err)
+		return
+	}
+
+	logger.Debug("Overwriting repo config")
+	// remove personal access token from remote URLs
+	if err := updateRepoConfig(conf, nil, repository); err != nil {
+		logger.Debugf("Failed to update repo config: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	remotes := []string{git.DefaultRemoteName}
+	if repo.ParentURL != "" {
+		if err := ensureUpstreamRemote(repository, conf.GetParentURL(repo)); err != nil {
+			logger.Debugf("Failed to create mirror: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		remotes = append(remotes, "upstream")
+	}
+
+	var counts []any
+	for _, remoteName := range remotes {
+		options, err := newFetchOptions(conf, repo, repository, remoteName)
+		if err != nil {
+			logger.Debugf("Failed to prepare fetch options: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		logger.Debugf("Fetching %s", remoteName)
+		count, err := fetchRemote(repository, options)
+		if err != nil {
+			logger.Debugf("Failed to fetch %s: %v", remoteName, err)
+			status.appendRow(repo.Directory, fmt.Errorf("%s: %w", remoteName, err))
+			return
+		}
+
+		counts = append(counts, count)
+	}
+
+	if err := writeFetchHead(conf, repository, git.DefaultRemoteName); err != nil {
+		logger.Debugf("Failed to record fetch: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	status.appendRow(repo.Directory, append([]any{"ok"}, counts...)...)
+}
+
+// fetchRemote fetches remote-tracking references of the remote given in options and counts new commits.
+func fetchRemote(repository *git.Repository, options *git.FetchOptions) (int, error) {
+	before, err := listRemoteTrackingRefs(repository, options.RemoteName)
+	if err != nil {
+		return 0, err
+	}
+
+	switch err := repository.Fetch(options); {
+
+	case errors.Is(err, git.NoErrAlreadyUpToDate):
+		return 0, nil
+
+	case err != nil:
+		return 0, err
+
+	}
+
+	after, err := listRemoteTrackingRefs(repository, options.RemoteName)
+	if err != nil {
+		return 0, err
+	}
+
+	// only commits between the previous and the new tips are walked
+	fetched, _, err := countExclusiveCommits(repository, slices.Collect(maps.Values(after)), slices.Collect(maps.Values(before)))
+	if err != nil {
+		return 0, err
+	}
+
+	return fetched, nil
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	object "github.com/go-git/go-git/v5/plumbing/object"
+	client "github.com/go-git/go-git/v5/plumbing/transport/client"
+	file "github.com/go-git/go-git/v5/plumbing/transport/file"
+	server "github.com/go-git/go-git/v5/plumbing/transport/server"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+// serveFileProtocol serves repositories addressed by local paths in-process, so that tests do not depend on the git binary.
+// Repositories on disk (server.DefaultLoader) are addressed by their git directory, e.g. "<work tree>/.git".
+func serveFileProtocol(tb testing.TB, loader server.Loader) {
+	tb.Helper()
+
+	client.InstallProtocol("file", server.NewServer(loader))
+	tb.Cleanup(func() { client.InstallProtocol("file", file.DefaultClient) })
+}
+
+// commitFiles writes given files into the work tree and commits them.
+func commitFiles(tb testing.TB, workTree *git.Worktree, message string, files map[string]string) plumbing.Hash {
+	tb.Helper()
+
+	for name, content := range files {
+		file, err := workTree.Filesystem.Create(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		_, _ = file.Write([]byte(content))
+		_ = file.Close()
+
+		if _, err := workTree.Add(name); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	hash, err := workTree.Commit(message, &git.CommitOptions{
+		Author:            &object.Signature{Name: "tester", Email: "tester@example.com", When: time.Now()},
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return hash
+}
+
+func TestFetchOperation(t *testing.T) {
+	serveFileProtocol(t, server.DefaultLoader)
+
+	for _, tt := range []struct {
+		name    string
+		commits int
+		branch  bool
+		want    []string
+	}{
+		{"test#1", 0, false, []string{"local", "ok", "0"}},
+		{"test#2", 2, false, []string{"local", "ok", "2"}},
+		{"test#3", 2, true, []string{"local", "ok", "3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, err := git.PlainInit(filepath.Join(t.TempDir(), "remote"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			remoteTree, err := remote.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_ = commitFiles(t, remoteTree, "base", map[string]string{"a.txt": "a\n"})
+
+			dir := t.TempDir()
+			remoteURL := filepath.Join(remoteTree.Filesystem.Root(), git.GitDirName)
+			local, err := git.PlainClone(filepath.Join(dir, "local"), false, &git.CloneOptions{URL: remoteURL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tips := make(map[plumbing.ReferenceName]plumbing.Hash)
+			for i := range tt.commits {
+				tips[plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "master")] = commitFiles(t, remoteTree, "remote", map[string]string{"a.txt": strings.Repeat("a\n", i+2)})
+			}
+
+			if tt.branch {
+				if err := remoteTree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+					t.Fatal(err)
+				}
+
+				tips[plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "feature")] = commitFiles(t, remoteTree, "feature", map[string]string{"b.txt": "b\n"})
+			}
+
+			// changes of the work tree must not prevent fetching
+			if err := os.WriteFile(filepath.Join(dir, "local", "a.txt"), []byte("dirty\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			head, err := local.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status := newOperationStatus()
+			fetchOperation(nil, newOperationContext(operationContextMap{
+				"conf":   &configfile.Configuration{AbsoluteDirectoryPath: dir},
+				"object": configfile.Repository{Directory: "local", URL: remoteURL},
+				"status": status,
+			}))
+
+			if got := strings.Fields(status.Sprint()); !slices.Equal(got, tt.want) {
+				t.Errorf("fetchOperation() failed: got: %v, want: %v", got, tt.want)
+			}
+
+			refs, err := listRemoteTrackingRefs(local, git.DefaultRemoteName)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, tip := range tips {
+				if refs[name] != tip {
+					t.Errorf("fetchOperation() failed: got %s: %s, want: %s", name, refs[name], tip)
+				}
+			}
+
+			// neither the checked out branch nor the work tree are modified
+			if got, err := local.Head(); err != nil || got.Hash() != head.Hash() {
+				t.Errorf("fetchOperation() failed: got HEAD: %v, want: %s", got, head.Hash())
+			}
+
+			if got, err := os.ReadFile(filepath.Join(dir, "local", "a.txt")); err != nil || string(got) != "dirty\n" {
+				t.Errorf("fetchOperation() failed: got a.txt: %q, want: %q", got, "dirty\n")
+			}
+		})
+	}
+}
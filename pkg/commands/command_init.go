@@ -1,10 +1,37 @@
 package commands
 
 import (
+	"fmt"
+	"slices"
+
+	color "github.com/fatih/color"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	supererrors "github.com/sarumaj/go-super/errors"
 	cobra "github.com/spf13/cobra"
 )
 
+// initFlags represents the flags for init command
+var initFlags struct {
+	strict bool
+}
+
+// profileFlags represents host specific flags for init and update commands
+var profileFlags struct {
+	caBundles       map[string]string
+	clientCertFiles map[string]string
+	clientKeyFiles  map[string]string
+	providers       map[string]string
+	proxies         map[string]string
+	sshKeyFiles     map[string]string
+	transports      map[string]string
+}
+
+// tokenSourceFlags represents flags for token sources of init command
+var tokenSourceFlags struct {
+	apps []string
+}
+
 // initCmd represents the init command
 var initCmd = func() *cobra.Command {
 	initCmd := &cobra.Command{
@@ -31,6 +58,37 @@ var initCmd = func() *cobra.Command {
 			"--concurrency 100 --timeout \"10s\" " +
 			"--dir \"/home/user/github\" --subdirs --sizelimit $((10*1024*1024)) --include \"(ORG1|ORG2)/.*\" --exclude \"ORG1/REPO1\"",
 		Run: func(*cobra.Command, []string) {
+			if !slices.Contains([]string{configfile.FetchPolicyTracking, configfile.FetchPolicyMirror}, configFlags.FetchPolicy) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported fetch policy: %q", configFlags.FetchPolicy))
+			}
+
+			if !slices.Contains(pullStrategies, configFlags.PullStrategy) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported pull strategy: %q", configFlags.PullStrategy))
+			}
+
+			if !slices.Contains(transports, configFlags.Transport) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported transport: %q", configFlags.Transport))
+			}
+
+			validateProfileFlags()
+
+			if _, err := getTagMode(configFlags.Tags); err != nil {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported tag mode: %q", configFlags.Tags))
+			}
+
+			if configFlags.CloneDepth < 0 {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Invalid clone depth: %d", configFlags.CloneDepth))
+			}
+
+			for _, spec := range tokenSourceFlags.apps {
+				app, err := parseGitHubApp(spec)
+				if err != nil {
+					util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Invalid GitHub App %q: %v", spec, err))
+				}
+
+				configFlags.TokenSources.Apps = append(configFlags.TokenSources.Apps, app)
+			}
+
 			// call copy to initialize all empty config fields
 			initializeOrUpdateConfig(configFlags.Copy(), false)
 		},
@@ -40,13 +98,53 @@ var initCmd = func() *cobra.Command {
 	}
 
 	flags := initCmd.Flags()
+	flags.BoolVar(&configFlags.AutoStash, "autostash", false, "Stash local changes of dirty repositories before pulling and restore them afterwards")
+	flags.IntVar(&configFlags.CloneDepth, "depth", 0, "Create shallow clones with history truncated to given number of commits (\"0\": full history)")
 	flags.StringVarP(&configFlags.BaseDirectory, "dir", "d", ".", "Directory in which repositories will be stored (either absolute or relative)")
+	flags.StringVar(&configFlags.DirectoryTemplate, "dir-template", "", "Template of repository directories relative to the base directory with placeholders {host}, {owner}, {repo} and {user} "+
+		"(e.g. \"{host}/{owner}/{repo}\" or \"{owner}-{repo}\", takes precedence over --subdirs)")
+	flags.StringVar(&configFlags.FetchPolicy, "fetch-policy", configfile.FetchPolicyTracking, fmt.Sprintf("Fetch policy used by pull (%q: fetch into remote-tracking branches and fast-forward local branches, %q: overwrite local references)", configfile.FetchPolicyTracking, configfile.FetchPolicyMirror))
+	flags.BoolVar(&configFlags.OfflineStatus, "offline-status", false, "Make status compare against locally stored remote-tracking references by default (see \"gr status --help\")")
+	flags.StringVar(&configFlags.PullStrategy, "pull-strategy", configfile.PullStrategyFastForwardOnly, fmt.Sprintf("Pull strategy for diverged branches (%q, %q or %q), can be overwritten for each repository", configfile.PullStrategyFastForwardOnly, configfile.PullStrategyMerge, configfile.PullStrategyRebase))
+	flags.BoolVar(&configFlags.SingleBranch, "single-branch", false, "Clone default branch only")
 	flags.BoolVarP(&configFlags.SubDirectories, "subdirs", "s", false, "Enable creation of separate subdirectories for each org/user")
 	flags.Uint64VarP(&configFlags.SizeLimit, "sizelimit", "l", 0, "Exclude repositories with size exceeded the limit (\"0\": no limit, e.g. limit of 52,428,800 corresponds with 50 MB)")
+	flags.StringVar(&configFlags.SSHKeyFile, "ssh-key", "", "Private key file used for SSH transport (\"\": use ssh-agent)")
+	addProfileFlags(initCmd)
+	flags.StringVar(&configFlags.SSHKnownHosts, "ssh-known-hosts", "", "Known hosts file used to verify SSH hosts (\"\": use defaults of OpenSSH)")
+	flags.StringVar(&configFlags.Tags, "tags", "", fmt.Sprintf("Tags to fetch (%q, %q or %q, defaults to the behavior of git)", configfile.TagModeAll, configfile.TagModeFollowing, configfile.TagModeNone))
+	flags.StringArrayVar(&tokenSourceFlags.apps, "token-app", []string{}, "GitHub App installation to retrieve tokens for "+
+		"(e.g. \"host=github.com,app-id=1,installation-id=2,private-key=~/app.pem\")")
+	flags.StringToStringVar(&configFlags.TokenSources.Environment, "token-env", map[string]string{}, "Environment variable to read the token of given host or account from "+
+		"(e.g. \"github.com=CI_TOKEN\" or \"octocat@github.com=CI_TOKEN\")")
+	flags.StringArrayVar(&configFlags.TokenSources.Files, "token-file", []string{}, "Credential file in the format of git-credential-store to read tokens from")
+	flags.StringVar(&configFlags.Transport, "transport", configfile.TransportHTTPS, fmt.Sprintf("Transport used to clone repositories (%q or %q)", configfile.TransportHTTPS, configfile.TransportSSH))
 	flags.StringArrayVarP(&configFlags.Excluded, "exclude", "e", []string{}, "Regular expressions for repositories to exclude")
 	flags.StringArrayVarP(&configFlags.Included, "include", "i", []string{}, "Regular expressions for repositories to include explicitly")
+	flags.BoolVar(&initFlags.strict, "strict", false, "Fail if any host could not be queried (by default, only if all hosts failed)")
 
 	supererrors.Except(initCmd.MarkFlagDirname("dir"))
 
 	return initCmd
 }()
+
+// validateProfileFlags exits if any of the host specific flags holds an unsupported value.
+func validateProfileFlags() {
+	for key, transportName := range profileFlags.transports {
+		if !slices.Contains(transports, transportName) {
+			util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported transport of %s: %q", key, transportName))
+		}
+	}
+}
+
+// addProfileFlags registers the host specific flags shared by init and update commands.
+func addProfileFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringToStringVar(&profileFlags.proxies, "host-proxy", map[string]string{}, "Proxy URL used for API requests and git transports over HTTP(S) of given host (e.g. \"github.example.com=http://proxy.example.com:8080\")")
+	flags.StringToStringVar(&profileFlags.caBundles, "host-ca-bundle", map[string]string{}, "CA bundle (PEM) complementing the system certificates for given host (e.g. \"github.example.com=/etc/ssl/internal-ca.pem\")")
+	flags.StringToStringVar(&profileFlags.clientCertFiles, "host-client-cert", map[string]string{}, "Client certificate (PEM) presented to given host (e.g. \"github.example.com=~/client.pem\")")
+	flags.StringToStringVar(&profileFlags.clientKeyFiles, "host-client-key", map[string]string{}, "Private key (PEM) of the client certificate of given host, unless contained in the certificate file (e.g. \"github.example.com=~/client.key\")")
+	flags.StringToStringVar(&profileFlags.providers, "host-provider", map[string]string{}, fmt.Sprintf("Provider hosting the repositories of given host (%q or %q, e.g. \"gitea.example.com=%s\")", configfile.ProviderGitHub, configfile.ProviderGitea, configfile.ProviderGitea))
+	flags.StringToStringVar(&profileFlags.sshKeyFiles, "host-ssh-key", map[string]string{}, "Private key file used for SSH transport for given host or account (e.g. \"github.com=~/.ssh/id_ed25519\" or \"octocat@github.com=~/.ssh/id_ed25519\")")
+	flags.StringToStringVar(&profileFlags.transports, "host-transport", map[string]string{}, "Transport used to clone repositories of given host or account (e.g. \"github.com=ssh\" or \"octocat@github.com=ssh\")")
+}
@@ -3,7 +3,10 @@ package commands
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	color "github.com/fatih/color"
@@ -29,6 +32,7 @@ var prFlags struct {
 	filters         []string
 	labels          []string
 	titles          []string
+	rest            bool
 	web             bool
 }
 
@@ -52,7 +56,10 @@ var prCmd = func() *cobra.Command {
 			"\t- back reference \\1\n" +
 			"\t- named back reference \\k'name'\n" +
 			"\t- named ascii character class [[:foo:]]\n" +
-			"\t- conditionals (?(expr)yes|no)\n",
+			"\t- conditionals (?(expr)yes|no)\n\n" +
+			"Pull requests are listed through batched queries of the GraphQL API, including their review state and check status.\n" +
+			"The REST API is used for hosts not supporting GraphQL or if requested explicitly.\n" +
+			"Pull requests of Gitea hosts are listed through the Gitea API, search qualifiers are evaluated locally.",
 		Example: "gh gr pr --state open",
 		Run: func(*cobra.Command, []string) {
 			c := util.Console()
@@ -90,6 +97,7 @@ var prCmd = func() *cobra.Command {
 	flags.StringArrayVar(&prFlags.filters, "match", []string{}, "Glob pattern(s) to filter pull request repositories")
 	flags.StringArrayVar(&prFlags.labels, "label", []string{}, "Glob pattern(s) to filter pull request labels")
 	flags.StringArrayVar(&prFlags.titles, "title", []string{}, "Regular expression(s) to filter pull request titles")
+	flags.BoolVar(&prFlags.rest, "rest", false, "List pull requests through the REST API instead of the GraphQL API")
 
 	prCmd.AddCommand(prCloseCmd, prReopenCmd)
 
@@ -97,7 +105,13 @@ var prCmd = func() *cobra.Command {
 }()
 
 // pullRequestAction represents a singular action on a pull request.
-type pullRequestAction func(*restclient.RESTClient) func(context.Context, string, string, int) error
+type pullRequestAction func(restclient.Provider) func(context.Context, string, string, int) error
+
+// pullRequestsResult represents pull requests of a repository retrieved in advance.
+type pullRequestsResult struct {
+	pulls []resources.PullRequest
+	err   error
+}
 
 // buildPullSearchQuery builds a search query for pull requests.
 func buildPullSearchQuery() map[string]string {
@@ -174,12 +188,28 @@ func buildPullSearchQuery() map[string]string {
 	return filter
 }
 
+// describePullRequestState describes the review state or check status of a pull request.
+func describePullRequestState(state string) any {
+	switch description := strings.ReplaceAll(strings.ToLower(state), "_", " "); description {
+
+	case "", "approved", "success":
+		return description
+
+	default:
+		return fmt.Errorf("%s", description)
+
+	}
+}
+
 // listPullRequests initializes pull requests.
 func listPullRequests(conf *configfile.Configuration, filter map[string]string, list *configfile.PullRequestList, silent bool) {
+	prefetched := prefetchPullRequests(conf, filter)
+
 	operationLoop[configfile.Repository](prListOperation, "PRs list", operationContextMap{
-		"filter": filter,
-		"cache":  make(map[string]*restclient.RESTClient),
-		"list":   list,
+		"filter":     filter,
+		"prefetched": prefetched,
+		"cache":      make(map[string]restclient.Provider),
+		"list":       list,
 		"keep": func(pull configfile.PullRequest) bool {
 			switch {
 			case
@@ -195,7 +225,7 @@ func listPullRequests(conf *configfile.Configuration, filter map[string]string,
 			}
 			return true
 		},
-		"headers": []string{"Title", "Number", "State", "Repository", "Author", "Assignees", "Labels"},
+		"headers": []string{"Title", "Number", "State", "Repository", "Author", "Assignees", "Labels", "Review", "Checks"},
 		"silent":  silent,
 	})
 
@@ -204,12 +234,135 @@ func listPullRequests(conf *configfile.Configuration, filter map[string]string,
 	}
 }
 
+// prefetchPullRequests lists pull requests of all repositories through batched queries of the GraphQL API.
+// Search queries are grouped by owner into combined queries, which are issued through the REST API,
+// if the GraphQL API failed (e.g. not supported by the host) or has been opted out of.
+// Other repositories of profiles, for which the GraphQL API failed, are omitted to be listed through the REST API instead.
+func prefetchPullRequests(conf *configfile.Configuration, filter map[string]string) map[string]pullRequestsResult {
+	logger := loggerEntry.WithField("command", "pr")
+
+	query := filter["q"]
+	if prFlags.rest && query == "" {
+		return make(map[string]pullRequestsResult)
+	}
+
+	type profileRepositories struct {
+		profile      configfile.Profile
+		repositories map[string][]configfile.Repository
+	}
+
+	profiles := make(map[string]*profileRepositories)
+	for _, repo := range conf.Repositories {
+		// the GraphQL and search APIs are specific to GitHub, repositories of other providers are listed individually
+		profile, ok := conf.GetProfile(repo)
+		if !ok || profile.GetProvider() != configfile.ProviderGitHub {
+			continue
+		}
+
+		group, found := profiles[profile.Key()]
+		if !found {
+			group = &profileRepositories{profile: profile, repositories: make(map[string][]configfile.Repository)}
+			profiles[profile.Key()] = group
+		}
+
+		slug := configfile.GetRepositorySlugFromURL(repo)
+		group.repositories[slug] = append(group.repositories[slug], repo)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]pullRequestsResult)
+	for key, group := range profiles {
+		token, found := conf.GetToken(group.profile)
+		if !found {
+			logger.Warnf("Failed to retrieve token for profile: %q", key)
+			continue
+		}
+
+		options := restclient.ClientOptions{
+			AuthToken:   token,
+			Log:         logger.WriterLevel(logrus.DebugLevel),
+			LogColorize: util.Console().ColorsEnabled(),
+			Host:        group.profile.Host,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+			defer cancel()
+
+			slugs := slices.Sorted(maps.Keys(group.repositories))
+			pulls, errs, err := fetchPullRequests(ctx, conf, options, group.profile.Username, slugs, filter)
+			if err != nil {
+				logger.Warnf("Failed to retrieve pull requests for profile %q, falling back to REST API: %v", key, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for slug, repositories := range group.repositories {
+				for _, repo := range repositories {
+					results[repo.Directory] = pullRequestsResult{pulls: pulls[slug], err: errs[slug]}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// fetchPullRequests lists pull requests of given repositories (<owner>/<name>) of a single profile.
+// It uses the GraphQL API, unless opted out of, and falls back to combined search queries of the REST API for search queries.
+func fetchPullRequests(
+	ctx context.Context,
+	conf *configfile.Configuration,
+	options restclient.ClientOptions,
+	user string,
+	slugs []string,
+	filter map[string]string,
+) (map[string][]resources.PullRequest, map[string]error, error) {
+	query := filter["q"]
+
+	if !prFlags.rest {
+		client, err := restclient.NewGraphQLClient(conf, options, globalNonPersistentFlags.retry)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var pulls map[string][]resources.PullRequest
+		var errs map[string]error
+		if query != "" {
+			pulls, errs, err = client.SearchReposPulls(ctx, user, slugs, query)
+		} else {
+			pulls, errs, err = client.GetReposPulls(ctx, slugs, filter)
+		}
+
+		if err == nil || query == "" {
+			return pulls, errs, err
+		}
+
+		loggerEntry.WithField("command", "pr").Debugf("Failed to search pull requests through GraphQL API: %v", err)
+	}
+
+	client, err := restclient.NewRESTClient(conf, options, globalNonPersistentFlags.retry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pulls, errs := client.SearchReposPulls(ctx, user, slugs, query)
+	return pulls, errs, nil
+}
+
 // prDoOperation performs an operation on a pull request.
 func prDoOperation(_ pool.WorkUnit, args operationContext) {
 	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
 	pr := unwrapOperationContext[configfile.PullRequest](args, "object")
 	status := unwrapOperationContext[*operationStatus](args, "status")
-	cache := unwrapOperationContext[map[string]*restclient.RESTClient](args, "cache")
+	cache := unwrapOperationContext[map[string]restclient.Provider](args, "cache")
 	action := unwrapOperationContext[pullRequestAction](args, "action")
 	newState := unwrapOperationContext[string](args, "newState")
 
@@ -220,33 +373,36 @@ func prDoOperation(_ pool.WorkUnit, args operationContext) {
 		return
 	}
 
-	host := util.GetHostnameFromPath(pr.URL)
-	client, ok := cache[host]
-
+	profile, ok := conf.Profiles.ToMap()[pr.Profile]
 	if !ok {
-		token, ok := configfile.GetTokens()[host]
-		if !ok {
-			logger.Warnf("Failed to retrieve token for host: %q", host)
+		profile, ok = conf.Profiles.ForHost(util.GetHostnameFromPath(pr.URL))
+	}
+
+	client, cached := cache[profile.Key()]
+	if !cached {
+		token, found := conf.GetToken(profile)
+		if !ok || !found {
+			logger.Warnf("Failed to retrieve token for profile: %q", profile.Key())
 			pr.Error = configfile.PullRequestError("failed to retrieve token")
 			status.appendRow(pr.Title, pr.Number, pr.Status(), pr.Author, pr.Assignees, pr.Labels)
 			return
 		}
 
 		var err error
-		client, err = restclient.NewRESTClient(conf, restclient.ClientOptions{
+		client, err = restclient.NewProvider(conf, profile.GetProvider(), restclient.ClientOptions{
 			AuthToken:   token,
 			Log:         logger.WriterLevel(logrus.DebugLevel),
 			LogColorize: util.Console().ColorsEnabled(),
-			Host:        host,
+			Host:        profile.Host,
 		}, globalNonPersistentFlags.retry)
 		if err != nil {
-			logger.Warnf("Failed to create REST client: %v", err)
+			logger.Warnf("Failed to create API client: %v", err)
 			pr.Error = configfile.PullRequestError("failed to retrieve token")
 			status.appendRow(pr.Title, pr.Number, pr.Status(), pr.Author, pr.Assignees, pr.Labels)
 			return
 		}
 
-		cache[host] = client
+		cache[profile.Key()] = client
 	}
 
 	owner, repo, _ := strings.Cut(pr.Repository, "/")
@@ -266,67 +422,72 @@ func prDoOperation(_ pool.WorkUnit, args operationContext) {
 }
 
 // prListOperation lists pull requests.
-// Depending on the filter, it will use either pull requests API endpoint or the search API endpoint.
+// Unless retrieved in advance, it will use either pull requests API endpoint or the search API endpoint depending on the filter.
 func prListOperation(_ pool.WorkUnit, args operationContext) {
 	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
 	repo := unwrapOperationContext[configfile.Repository](args, "object")
 	status := unwrapOperationContext[*operationStatus](args, "status")
 	keep := unwrapOperationContext[func(configfile.PullRequest) bool](args, "keep")
 	filter := unwrapOperationContext[map[string]string](args, "filter")
-	cache := unwrapOperationContext[map[string]*restclient.RESTClient](args, "cache")
+	cache := unwrapOperationContext[map[string]restclient.Provider](args, "cache")
 	list := unwrapOperationContext[*configfile.PullRequestList](args, "list")
+	prefetched := unwrapOperationContext[map[string]pullRequestsResult](args, "prefetched")
 
 	logger := loggerEntry.WithField("command", "pr").WithField("repository", repo.Directory)
 
-	host := util.GetHostnameFromPath(repo.URL)
-	client, ok := cache[host]
-	if !ok {
-		token, ok := configfile.GetTokens()[host]
-		if !ok {
-			logger.Warnf("Failed to retrieve token for host: %q", host)
-			status.appendRow("", "", fmt.Errorf("failed to retrieve token"), repo.Directory, "", []string{}, []string{})
-			return
-		}
+	profile, ok := conf.GetProfile(repo)
+	result, found := prefetched[repo.Directory]
+	if !found {
+		client, cached := cache[profile.Key()]
+		if !cached {
+			token, found := conf.GetToken(profile)
+			if !ok || !found {
+				logger.Warnf("Failed to retrieve token for profile: %q", profile.Key())
+				status.appendRow("", "", fmt.Errorf("failed to retrieve token"), repo.Directory, "", []string{}, []string{}, "", "")
+				return
+			}
 
-		var err error
-		client, err = restclient.NewRESTClient(conf, restclient.ClientOptions{
-			AuthToken:   token,
-			Log:         logger.WriterLevel(logrus.DebugLevel),
-			LogColorize: util.Console().ColorsEnabled(),
-			Host:        host,
-		}, globalNonPersistentFlags.retry)
-		if err != nil {
-			logger.Warnf("Failed to create REST client: %v", err)
-			status.appendRow("", "", err, repo.Directory, "", []string{}, []string{})
-			return
-		}
+			var err error
+			client, err = restclient.NewProvider(conf, profile.GetProvider(), restclient.ClientOptions{
+				AuthToken:   token,
+				Log:         logger.WriterLevel(logrus.DebugLevel),
+				LogColorize: util.Console().ColorsEnabled(),
+				Host:        profile.Host,
+			}, globalNonPersistentFlags.retry)
+			if err != nil {
+				logger.Warnf("Failed to create API client: %v", err)
+				status.appendRow("", "", err, repo.Directory, "", []string{}, []string{}, "", "")
+				return
+			}
 
-		cache[host] = client
-	}
+			cache[profile.Key()] = client
+		}
 
-	slug := configfile.GetRepositorySlugFromURL(repo)
-	owner, repoName, _ := strings.Cut(slug, "/")
+		slug := configfile.GetRepositorySlugFromURL(repo)
+		owner, repoName, _ := strings.Cut(slug, "/")
 
-	var pulls []resources.PullRequest
-	var err error
-	if query := filter["q"]; query != "" {
-		pulls, err = client.SearchOrgRepoPulls(args.Context, owner, repoName, query)
-	} else {
-		pulls, err = client.GetOrgRepoPulls(args.Context, owner, repoName, filter)
+		if query := filter["q"]; query != "" {
+			result.pulls, result.err = client.SearchOrgRepoPulls(args.Context, owner, repoName, query)
+		} else {
+			result.pulls, result.err = client.GetOrgRepoPulls(args.Context, owner, repoName, filter)
+		}
 	}
-	if err != nil {
-		loggerEntry.Warnf("Failed to retrieve pull requests: %v", err)
-		status.appendRow("", "", err, repo.Directory, "", []string{}, []string{})
+
+	if result.err != nil {
+		loggerEntry.Warnf("Failed to retrieve pull requests: %v", result.err)
+		status.appendRow("", "", result.err, repo.Directory, "", []string{}, []string{}, "", "")
 		return
 	}
 
-	for _, pr := range pulls {
+	for _, pr := range result.pulls {
 		entry := configfile.PullRequestFromResponse(pr)
+		entry.Profile = profile.Key()
 		if !keep(entry) {
 			continue
 		}
 
-		status.appendRow(entry.Title, entry.Number, entry.Status(), entry.Repository, entry.Author, entry.Assignees, entry.Labels)
+		status.appendRow(entry.Title, entry.Number, entry.Status(), entry.Repository, entry.Author, entry.Assignees, entry.Labels,
+			describePullRequestState(entry.Review), describePullRequestState(entry.Checks))
 		list.Append(entry)
 	}
 }
@@ -33,8 +33,8 @@ var prReopenCmd = func() *cobra.Command {
 			}
 
 			operationLoop(prDoOperation, "Reopen", operationContextMap{
-				"cache": make(map[string]*restclient.RESTClient),
-				"action": pullRequestAction(func(client *restclient.RESTClient) func(context.Context, string, string, int) error {
+				"cache": make(map[string]restclient.Provider),
+				"action": pullRequestAction(func(client restclient.Provider) func(context.Context, string, string, int) error {
 					return client.ReopenPullRequest
 				}),
 				"newState": "open",
@@ -3,33 +3,114 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	color "github.com/fatih/color"
 	git "github.com/go-git/go-git/v5"
 	gitconfig "github.com/go-git/go-git/v5/config"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	object "github.com/go-git/go-git/v5/plumbing/object"
 	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
 	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	cobra "github.com/spf13/cobra"
 	pool "gopkg.in/go-playground/pool.v3"
 )
 
-// pullCmd represents the pull command
-var pullCmd = &cobra.Command{
-	Use:     "pull",
-	Short:   "Pull all repositories",
-	Example: "gh pr pull",
-	Run: func(*cobra.Command, []string) {
-		operationLoop[configfile.Repository](pullOperation, "Pull", operationContextMap{
-			"headers": []string{"Directory", "Status"},
-		})
-	},
+// pullFlags represents the flags for pull command
+var pullFlags struct {
+	autoStash   bool
+	fetchPolicy string
+	strategy    string
 }
 
-// cloneRemoteRepository clones remote repository locally.
-func cloneRemoteRepository(repo configfile.Repository, status *operationStatus) (*git.Repository, *git.Worktree, error) {
-	repository, err := git.PlainClone(repo.Directory, false, &git.CloneOptions{
-		URL:               repo.URL,
+// Supported pull strategies.
+var pullStrategies = []string{configfile.PullStrategyFastForwardOnly, configfile.PullStrategyMerge, configfile.PullStrategyRebase}
+
+// pullCmd represents the pull command
+var pullCmd = func() *cobra.Command {
+	pullCmd := &cobra.Command{
+		Use:   "pull",
+		Short: "Pull all repositories",
+		Long: "Pull all repositories.\n\n" +
+			"By default, remote references are fetched into remote-tracking branches " +
+			"and local branches are fast-forwarded where possible.\n" +
+			"Local branches, which have diverged from their remote counterparts, are listed and left untouched.\n" +
+			"If the checked out branch has diverged, it is handled according to the pull strategy:\n\n" +
+			"\t- \"" + configfile.PullStrategyFastForwardOnly + "\": report the number of commits ahead and behind\n" +
+			"\t- \"" + configfile.PullStrategyMerge + "\": create a merge commit\n" +
+			"\t- \"" + configfile.PullStrategyRebase + "\": replay local commits onto the remote branch\n\n" +
+			"Merging and rebasing is performed on file level. Files changed on both sides are reported as conflicts " +
+			"and the branch is left untouched.\n" +
+			"Dirty repositories are skipped unless auto-stash is enabled. " +
+			"In that case, local changes are stashed before pulling and restored afterwards.\n" +
+			"If the restoration conflicts, the stash is kept at \"" + autoStashRef.String() + "\" " +
+			"and the work tree is left at the pulled state.\n" +
+			"New repositories are cloned according to the configured depth, single-branch and tag settings. " +
+			"Shallow clones remain shallow on subsequent pulls.\n" +
+			"Partial (blobless) clones are not supported, since go-git does not implement object filters.",
+		Example: "gh pr pull",
+		Run: func(cmd *cobra.Command, _ []string) {
+			if pullFlags.fetchPolicy != "" && !slices.Contains([]string{configfile.FetchPolicyTracking, configfile.FetchPolicyMirror}, pullFlags.fetchPolicy) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported fetch policy: %q", pullFlags.fetchPolicy))
+			}
+
+			if pullFlags.strategy != "" && !slices.Contains(pullStrategies, pullFlags.strategy) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported pull strategy: %q", pullFlags.strategy))
+			}
+
+			autoStash := configFlags.AutoStash
+			if cmd.Flags().Changed("autostash") {
+				autoStash = pullFlags.autoStash
+			}
+
+			operationLoop[configfile.Repository](pullOperation, "Pull", operationContextMap{
+				"autoStash":   autoStash,
+				"fetchPolicy": pullFlags.fetchPolicy,
+				"strategy":    pullFlags.strategy,
+				"headers":     []string{"Directory", "Status"},
+			})
+		},
+	}
+
+	flags := pullCmd.Flags()
+	flags.BoolVar(&pullFlags.autoStash, "autostash", false, "Stash local changes of dirty repositories before pulling and restore them afterwards (overwrites configured default)")
+	flags.StringVar(&pullFlags.strategy, "strategy", "", fmt.Sprintf("Overwrite configured pull strategy (%q, %q or %q)", configfile.PullStrategyFastForwardOnly, configfile.PullStrategyMerge, configfile.PullStrategyRebase))
+	flags.StringVar(&pullFlags.fetchPolicy, "fetch-policy", "", fmt.Sprintf("Overwrite configured fetch policy (%q or %q)", configfile.FetchPolicyTracking, configfile.FetchPolicyMirror))
+
+	return pullCmd
+}()
+
+// cloneRemoteRepository clones remote repository locally according to its clone settings.
+func cloneRemoteRepository(conf *configfile.Configuration, repo configfile.Repository, status *operationStatus) (*git.Repository, *git.Worktree, error) {
+	tags, err := getTagMode(conf.GetTagMode(repo))
+	if err != nil {
+		status.appendRow(repo.Directory, err)
+		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
+	}
+
+	remoteURL := conf.GetRemoteURL(repo)
+	auth, err := conf.GetAuthMethod(repo, remoteURL)
+	if err != nil {
+		status.appendRow(repo.Directory, err)
+		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
+	}
+
+	options := &git.CloneOptions{
+		URL:               remoteURL,
+		Auth:              auth,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-	})
+		Depth:             conf.GetCloneDepth(repo),
+		ShallowSubmodules: conf.GetCloneDepth(repo) > 0,
+		SingleBranch:      conf.GetSingleBranch(repo),
+		Tags:              tags,
+	}
+
+	if options.SingleBranch && repo.Branch != "" {
+		options.ReferenceName = plumbing.NewBranchReferenceName(repo.Branch)
+	}
+
+	repository, err := git.PlainClone(repo.Directory, false, options)
 	if err != nil {
 		status.appendRow(repo.Directory, err)
 		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
@@ -44,13 +125,109 @@ func cloneRemoteRepository(repo configfile.Repository, status *operationStatus)
 	return repository, workTree, nil
 }
 
+// fetchRepository updates references of local repository according to given fetch policy.
+// Policy "tracking" fetches into remote-tracking references and fast-forwards local branches where possible.
+// Names of local branches, which have diverged from their remote-tracking counterparts, are returned.
+// Policy "mirror" overwrites local references with the remote ones (remote-tracking references are updated as well).
+func fetchRepository(repository *git.Repository, policy string, options *git.FetchOptions) (diverged []string, err error) {
+	switch policy {
+
+	case configfile.FetchPolicyMirror:
+		mirror := *options
+		mirror.RefSpecs = []gitconfig.RefSpec{
+			"refs/*:refs/*",
+			gitconfig.RefSpec(fmt.Sprintf(gitconfig.DefaultFetchRefSpec, options.RemoteName)),
+		}
+
+		if err := repository.Fetch(&mirror); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, err
+		}
+
+		return nil, nil
+
+	case configfile.FetchPolicyTracking:
+		if err := repository.Fetch(options); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported fetch policy: %q", policy)
+
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	repoConf, err := repository.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := repository.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	err = branches.ForEach(func(branch *plumbing.Reference) error {
+		// checked out branch is handled by the work tree
+		if branch.Name() == head.Name() {
+			return nil
+		}
+
+		upstreamName, _ := getUpstreamReferenceName(repoConf, branch.Name().Short())
+		upstream, err := repository.Reference(upstreamName, true)
+		switch {
+
+		case errors.Is(err, plumbing.ErrReferenceNotFound):
+			return nil
+
+		case err != nil:
+			return err
+
+		case upstream.Hash() == branch.Hash():
+			return nil
+
+		}
+
+		ahead, behind, err := countAheadBehind(repository, branch.Hash(), upstream.Hash())
+		switch {
+
+		case err != nil:
+			return err
+
+		case behind == 0: // nothing to fast-forward
+
+		case ahead == 0:
+			return repository.Storer.SetReference(plumbing.NewHashReference(branch.Name(), upstream.Hash()))
+
+		default:
+			diverged = append(diverged, branch.Name().Short())
+
+		}
+
+		return nil
+	})
+
+	return diverged, err
+}
+
 // pullExistingRepository pulls remote repository.
-func pullExistingRepository(repo configfile.Repository, status *operationStatus) (*git.Repository, *git.Worktree, error) {
+// If autoStash is set, changes of a dirty work tree are stashed before and restored after pulling.
+// Diverged branches are handled according to given pull strategy.
+func pullExistingRepository(conf *configfile.Configuration, repo configfile.Repository, status *operationStatus, autoStash bool, strategy string) (*git.Repository, *git.Worktree, error) {
 	repository, err := openRepository(repo, status)
 	if err != nil {
 		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
 	}
 
+	auth, err := getRemoteAuthMethod(conf, repo, repository, git.DefaultRemoteName)
+	if err != nil {
+		status.appendRow(repo.Directory, err)
+		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
+	}
+
 	workTree, err := repository.Worktree()
 	if err != nil {
 		status.appendRow(repo.Directory, err)
@@ -63,14 +240,43 @@ func pullExistingRepository(repo configfile.Repository, status *operationStatus)
 		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
 	}
 
-	if !repoStatus.IsClean() {
+	stashed := false
+	switch {
+
+	case repoStatus.IsClean(): // nothing to stash
+
+	case !autoStash:
 		status.appendRow(repo.Directory, git.ErrWorktreeNotClean)
 		return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, git.ErrWorktreeNotClean)
+
+	default:
+		if err := stashChanges(repository, workTree); err != nil {
+			status.appendRow(repo.Directory, err)
+			return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
+		}
+
+		stashed = true
+
 	}
 
-	switch err = workTree.Pull(&git.PullOptions{
+	err = workTree.Pull(&git.PullOptions{
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-	}); {
+		Auth:              auth,
+	})
+
+	if errors.Is(err, git.ErrNonFastForwardUpdate) {
+		err = reconcileDivergedBranch(repository, workTree, strategy)
+	}
+
+	// restore stashed changes regardless whether pulling succeeded
+	if stashed {
+		if err := restoreStash(repository, workTree); err != nil {
+			status.appendRow(repo.Directory, fmt.Errorf("%w (stash kept at %s)", err, autoStashRef))
+			return nil, nil, fmt.Errorf("repository %s: %w", repo.Directory, err)
+		}
+	}
+
+	switch {
 
 	case errors.Is(err, git.NoErrAlreadyUpToDate): // ignore
 
@@ -83,31 +289,96 @@ func pullExistingRepository(repo configfile.Repository, status *operationStatus)
 	return repository, workTree, nil
 }
 
+// mergeCommits creates a merge commit of local and remote commit based on a three-way merge on file level.
+func mergeCommits(repository *git.Repository, repoConf *gitconfig.Config, local, remote *plumbing.Reference) (plumbing.Hash, error) {
+	localCommit, err := repository.CommitObject(local.Hash())
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	remoteCommit, err := repository.CommitObject(remote.Hash())
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	bases, err := localCommit.MergeBase(remoteCommit)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if len(bases) == 0 {
+		return plumbing.ZeroHash, fmt.Errorf("no merge base for %s and %s", local.Name().Short(), remote.Name().Short())
+	}
+
+	base, err := listTreeEntries(repository, bases[0].Hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	ours, err := listTreeEntries(repository, localCommit.Hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	theirs, err := listTreeEntries(repository, remoteCommit.Hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	merged, conflicts := mergeTreeEntries(base, ours, theirs)
+	if len(conflicts) > 0 {
+		return plumbing.ZeroHash, fmt.Errorf("%w: %s", errConflict, strings.Join(conflicts, ", "))
+	}
+
+	treeHash, err := writeTree(repository, merged)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	signature := newSignature(repoConf)
+	return writeCommit(repository, &object.Commit{
+		Author:       *signature,
+		Committer:    *signature,
+		Message:      fmt.Sprintf("Merge remote-tracking branch '%s' into %s\n", remote.Name().Short(), local.Name().Short()),
+		TreeHash:     treeHash,
+		ParentHashes: []plumbing.Hash{local.Hash(), remote.Hash()},
+	})
+}
+
 // Pull remote repository.
 func pullOperation(_ pool.WorkUnit, args operationContext) {
 	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
 	repo := unwrapOperationContext[configfile.Repository](args, "object")
 	status := unwrapOperationContext[*operationStatus](args, "status")
+	fetchPolicy := unwrapOperationContext[string](args, "fetchPolicy")
+	autoStash := unwrapOperationContext[bool](args, "autoStash")
+	strategy := unwrapOperationContext[string](args, "strategy")
 
 	logger := loggerEntry.WithField("command", "pull").WithField("repository", repo.Directory)
 
-	conf.AuthenticateURL(&repo.URL)
-	conf.AuthenticateURL(&repo.ParentURL)
-	logger.Debugf("Authenticated: URL: %t, ParentURL: %t", repo.URL != "", repo.ParentURL != "")
-
 	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
 
+	if strategy == "" {
+		strategy = conf.GetPullStrategy(repo)
+	}
+
+	// strategies configured for repositories are not validated upfront
+	if !slices.Contains(pullStrategies, strategy) {
+		status.appendRow(repo.Directory, fmt.Errorf("unsupported pull strategy: %q", strategy))
+		return
+	}
+
 	var repository *git.Repository
 	var workTree *git.Worktree
 	var err error
 
 	if util.PathExists(repo.Directory) {
 		logger.Debug("Local repository exists")
-		repository, workTree, err = pullExistingRepository(repo, status)
+		repository, workTree, err = pullExistingRepository(conf, repo, status, autoStash, strategy)
 
 	} else {
 		logger.Debug("Cloning")
-		repository, workTree, err = cloneRemoteRepository(repo, status)
+		repository, workTree, err = cloneRemoteRepository(conf, repo, status)
 	}
 
 	if err != nil {
@@ -116,9 +387,15 @@ func pullOperation(_ pool.WorkUnit, args operationContext) {
 	}
 
 	logger.Debug("Overwriting repo config")
-	host := util.GetHostnameFromPath(repo.URL)
-	// update remote URL to use current personal access token
-	if err := updateRepoConfig(conf, host, repository); err != nil {
+	profile, ok := conf.GetProfile(repo)
+	if !ok {
+		logger.Debugf("No profile for repository: %s", repo.Profile)
+		status.appendRow(repo.Directory, fmt.Errorf("no profile for repository: %q", repo.Profile))
+		return
+	}
+
+	// set user of owning profile and remove personal access token from remote URLs
+	if err := updateRepoConfig(conf, &profile, repository); err != nil {
 		logger.Debugf("Failed to update repo config: %v", err)
 		status.appendRow(repo.Directory, err)
 		return
@@ -132,43 +409,216 @@ func pullOperation(_ pool.WorkUnit, args operationContext) {
 		return
 	}
 
+	if fetchPolicy == "" {
+		fetchPolicy = conf.GetFetchPolicy()
+	}
+
 	logger.Debugf("Pulling %d submodules", len(submodules))
 	for _, s := range submodules {
-		if err := pullSubmodule(s); err != nil {
+		if err := pullSubmodule(conf, repo, s, fetchPolicy); err != nil {
 			logger.Debugf("Failed to pull submodule: %v", err)
 			status.appendRow(repo.Directory, err)
 			return
 		}
 	}
 
-	if err := repository.Fetch(&git.FetchOptions{
-		RefSpecs: []gitconfig.RefSpec{"refs/*:refs/*"},
-	}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+	options, err := newFetchOptions(conf, repo, repository, git.DefaultRemoteName)
+	if err != nil {
+		logger.Debugf("Failed to prepare fetch options: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
 
+	logger.Debugf("Fetching with policy: %s", fetchPolicy)
+	diverged, err := fetchRepository(repository, fetchPolicy, options)
+	if err != nil {
+		logger.Debugf("Failed to fetch: %v", err)
 		status.appendRow(repo.Directory, err)
 		return
 	}
 
-	switch _, err := repository.Remote("upstream"); {
+	if err := writeFetchHead(conf, repository, git.DefaultRemoteName); err != nil {
+		logger.Debugf("Failed to record fetch: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
 
-	case repo.ParentURL != "" && errors.Is(err, git.ErrRemoteNotFound):
-		if _, err := repository.CreateRemote(&gitconfig.RemoteConfig{
-			Name: "upstream",
-			URLs: []string{repo.ParentURL},
-		}); err != nil {
+	if err := ensureUpstreamRemote(repository, conf.GetParentURL(repo)); err != nil {
+		logger.Debugf("Failed to create mirror: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
 
-			logger.Debugf("Failed to create mirror: %v", err)
-			status.appendRow(repo.Directory, err)
-			return
+	if len(diverged) > 0 {
+		logger.Debugf("Diverged branches: %v", diverged)
+		status.appendRow(repo.Directory, fmt.Errorf("diverged: %s", strings.Join(diverged, ", ")))
+		return
+	}
+
+	status.appendRow(repo.Directory, "ok")
+}
+
+// rebaseCommits replays local commits, which are not reachable from remote commit, onto the remote one.
+// Each commit is replayed using a three-way merge on file level.
+// Merge commits and commits becoming empty are dropped, hence the history gets linearized.
+func rebaseCommits(repository *git.Repository, repoConf *gitconfig.Config, local, remote plumbing.Hash) (plumbing.Hash, error) {
+	remoteAncestors, err := collectAncestors(repository, nil, remote)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	pending, err := collectAncestors(repository, remoteAncestors, local)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	// order local commits so that parents precede their children
+	var commits []*object.Commit
+	var visit func(plumbing.Hash) error
+	visit = func(hash plumbing.Hash) error {
+		if !pending[hash] {
+			return nil
+		}
+
+		delete(pending, hash)
+		commit, err := repository.CommitObject(hash)
+		if err != nil {
+			return err
+		}
+
+		for _, parent := range commit.ParentHashes {
+			if err := visit(parent); err != nil {
+				return err
+			}
 		}
 
+		// merge commits are dropped like native git does by default
+		if len(commit.ParentHashes) == 1 {
+			commits = append(commits, commit)
+		}
+
+		return nil
 	}
 
-	status.appendRow(repo.Directory, "ok")
+	if err := visit(local); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	remoteCommit, err := repository.CommitObject(remote)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	ours, err := listTreeEntries(repository, remote)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	tip, tipTree := remote, remoteCommit.TreeHash
+	for _, commit := range commits {
+		base, err := listTreeEntries(repository, commit.ParentHashes[0])
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		theirs, err := listTreeEntries(repository, commit.Hash)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		merged, conflicts := mergeTreeEntries(base, ours, theirs)
+		if len(conflicts) > 0 {
+			return plumbing.ZeroHash, fmt.Errorf("%w: %s (commit %s)", errConflict, strings.Join(conflicts, ", "), commit.Hash.String()[:7])
+		}
+
+		treeHash, err := writeTree(repository, merged)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		// changes are already contained in the remote commit
+		if treeHash == tipTree {
+			continue
+		}
+
+		tip, err = writeCommit(repository, &object.Commit{
+			Author:       commit.Author,
+			Committer:    *newSignature(repoConf),
+			Message:      commit.Message,
+			TreeHash:     treeHash,
+			ParentHashes: []plumbing.Hash{tip},
+		})
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		ours, tipTree = merged, treeHash
+	}
+
+	return tip, nil
+}
+
+// reconcileDivergedBranch integrates remote changes into the checked out branch, which cannot be fast-forwarded.
+// The branch is either merged with or rebased onto its upstream, or reported as diverged (see pull strategies).
+func reconcileDivergedBranch(repository *git.Repository, workTree *git.Worktree, strategy string) error {
+	head, err := repository.Head()
+	if err != nil {
+		return err
+	}
+
+	repoConf, err := repository.Config()
+	if err != nil {
+		return err
+	}
+
+	upstreamName, _ := getUpstreamReferenceName(repoConf, head.Name().Short())
+	upstream, err := repository.Reference(upstreamName, true)
+	if err != nil {
+		return err
+	}
+
+	ahead, behind, err := countAheadBehind(repository, head.Hash(), upstream.Hash())
+	if err != nil {
+		return err
+	}
+
+	var tip plumbing.Hash
+	switch {
+
+	case behind == 0: // nothing to integrate
+		return nil
+
+	case ahead == 0:
+		tip = upstream.Hash()
+
+	case strategy == configfile.PullStrategyFastForwardOnly:
+		return fmt.Errorf("%w (ahead %d, behind %d)", errDiverged, ahead, behind)
+
+	case strategy == configfile.PullStrategyMerge:
+		tip, err = mergeCommits(repository, repoConf, head, upstream)
+
+	case strategy == configfile.PullStrategyRebase:
+		tip, err = rebaseCommits(repository, repoConf, head.Hash(), upstream.Hash())
+
+	default:
+		return fmt.Errorf("unsupported pull strategy: %q", strategy)
+
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// moves the checked out branch to the new tip
+	return workTree.Reset(&git.ResetOptions{
+		Mode:   git.HardReset,
+		Commit: tip,
+	})
 }
 
 // Pull GitHub submodule.
-func pullSubmodule(submodule *git.Submodule) error {
+// References are fetched according to given fetch policy (see fetchRepository).
+func pullSubmodule(conf *configfile.Configuration, repo configfile.Repository, submodule *git.Submodule, fetchPolicy string) error {
 	status, err := submodule.Status()
 	if err != nil {
 		return fmt.Errorf("submodule: %w", err)
@@ -184,13 +634,18 @@ func pullSubmodule(submodule *git.Submodule) error {
 		return fmt.Errorf("submodule %s: %w", status.Path, err)
 	}
 
+	auth, err := getRemoteAuthMethod(conf, repo, repository, git.DefaultRemoteName)
+	if err != nil {
+		return fmt.Errorf("submodule %s: %w", status.Path, err)
+	}
+
 	if status.Branch == "" {
 		remote, err := repository.Remote(git.DefaultRemoteName)
 		if err != nil {
 			return fmt.Errorf("submodule %s: %w", status.Path, err)
 		}
 
-		remoteRefs, err := remote.List(&git.ListOptions{})
+		remoteRefs, err := remote.List(&git.ListOptions{Auth: auth})
 		if err != nil {
 			return fmt.Errorf("submodule %s: %w", status.Path, err)
 		}
@@ -200,14 +655,27 @@ func pullSubmodule(submodule *git.Submodule) error {
 				continue
 			}
 
-			if err := repository.Fetch(&git.FetchOptions{
-				RefSpecs: []gitconfig.RefSpec{"refs/*:refs/*"},
-			}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			if _, err := fetchRepository(repository, fetchPolicy, &git.FetchOptions{
+				RemoteName: git.DefaultRemoteName,
+				Auth:       auth,
+			}); err != nil {
 
 				return fmt.Errorf("submodule %s: %w", status.Path, err)
 			}
 
+			// the local branch is created from its remote-tracking counterpart, unless it exists already
 			branchRef := v.Target()
+			if _, err := repository.Reference(branchRef, false); errors.Is(err, plumbing.ErrReferenceNotFound) {
+				tracking, err := repository.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branchRef.Short()), true)
+				if err != nil {
+					return fmt.Errorf("submodule %s: %w", status.Path, err)
+				}
+
+				if err := repository.Storer.SetReference(plumbing.NewHashReference(branchRef, tracking.Hash())); err != nil {
+					return fmt.Errorf("submodule %s: %w", status.Path, err)
+				}
+			}
+
 			if err := repository.CreateBranch(&gitconfig.Branch{
 				Name:   branchRef.Short(),
 				Remote: git.DefaultRemoteName,
@@ -226,7 +694,7 @@ func pullSubmodule(submodule *git.Submodule) error {
 		}
 	}
 
-	switch err := worktree.Pull(&git.PullOptions{}); {
+	switch err := worktree.Pull(&git.PullOptions{Auth: auth}); {
 
 	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate): // ignore
 
@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+
+	memfs "github.com/go-git/go-billy/v5/memfs"
+	billyutil "github.com/go-git/go-billy/v5/util"
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	server "github.com/go-git/go-git/v5/plumbing/transport/server"
+	memory "github.com/go-git/go-git/v5/storage/memory"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+// setupDivergedRepository creates an in-memory repository, whose branch "master" has diverged from "origin/master".
+// Both sides start from a common commit, the local side writes localFiles and the remote side writes remoteFiles.
+func setupDivergedRepository(tb testing.TB, localFiles, remoteFiles map[string]string) (*git.Repository, *git.Worktree, plumbing.Hash) {
+	tb.Helper()
+
+	repository, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	workTree, err := repository.Worktree()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	base := commitFiles(tb, workTree, "base", map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	remote := commitFiles(tb, workTree, "remote", remoteFiles)
+	if err := repository.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "master"), remote)); err != nil {
+		tb.Fatal(err)
+	}
+
+	if err := workTree.Reset(&git.ResetOptions{Mode: git.HardReset, Commit: base}); err != nil {
+		tb.Fatal(err)
+	}
+
+	_ = commitFiles(tb, workTree, "local", localFiles)
+	return repository, workTree, remote
+}
+
+// readFile reads content of given file in the work tree.
+func readFile(tb testing.TB, workTree *git.Worktree, name string) string {
+	tb.Helper()
+
+	file, err := workTree.Filesystem.Open(name)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+
+	content, _ := io.ReadAll(file)
+	return string(content)
+}
+
+func TestReconcileDivergedBranch(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		strategy    string
+		local       map[string]string
+		wantErr     error
+		wantParents int
+	}{
+		{"test#1", configfile.PullStrategyMerge, map[string]string{"a.txt": "local\n"}, nil, 2},
+		{"test#2", configfile.PullStrategyRebase, map[string]string{"a.txt": "local\n"}, nil, 1},
+		{"test#3", configfile.PullStrategyFastForwardOnly, map[string]string{"a.txt": "local\n"}, errDiverged, 0},
+		{"test#4", configfile.PullStrategyMerge, map[string]string{"b.txt": "local\n"}, errConflict, 0},
+		{"test#5", configfile.PullStrategyRebase, map[string]string{"b.txt": "local\n"}, errConflict, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, workTree, remote := setupDivergedRepository(t, tt.local, map[string]string{"b.txt": "remote\n", "c.txt": "c\n"})
+			before, err := repository.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = reconcileDivergedBranch(repository, workTree, tt.strategy)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("reconcileDivergedBranch(%q) failed: got error: %v, want: %v", tt.strategy, err, tt.wantErr)
+			}
+
+			head, err := repository.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantErr != nil {
+				if head.Hash() != before.Hash() {
+					t.Errorf("reconcileDivergedBranch(%q) failed: branch moved to %s", tt.strategy, head.Hash())
+				}
+
+				return
+			}
+
+			commit, err := repository.CommitObject(head.Hash())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(commit.ParentHashes) != tt.wantParents {
+				t.Errorf("reconcileDivergedBranch(%q) failed: got %d parents, want: %d", tt.strategy, len(commit.ParentHashes), tt.wantParents)
+			}
+
+			// rebased commits are replayed onto the remote tip, merge commits have it as their second parent
+			if parent := commit.ParentHashes[len(commit.ParentHashes)-1]; parent != remote {
+				t.Errorf("reconcileDivergedBranch(%q) failed: got parent %s, want: %s", tt.strategy, parent, remote)
+			}
+
+			for name, want := range map[string]string{"a.txt": "local\n", "b.txt": "remote\n", "c.txt": "c\n"} {
+				if got := readFile(t, workTree, name); got != want {
+					t.Errorf("reconcileDivergedBranch(%q) failed: got %s: %q, want: %q", tt.strategy, name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchRepository(t *testing.T) {
+	repository, _, remote := setupDivergedRepository(t, map[string]string{"a.txt": "local\n"}, map[string]string{"b.txt": "remote\n"})
+	head, err := repository.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := repository.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := local.ParentHashes[0]
+
+	// the remote repository shares all objects, its branches point to the remote commit
+	storage := memory.NewStorage()
+	objects, err := repository.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := objects.ForEach(func(obj plumbing.EncodedObject) error {
+		_, err := storage.SetEncodedObject(obj)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, branch := range []string{"master", "behind", "diverged"} {
+		if err := storage.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), remote)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remoteURL := "file:///remote"
+	serveFileProtocol(t, server.MapLoader{remoteURL: storage})
+	if _, err := repository.CreateRemote(&gitconfig.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remoteURL}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// branch "behind" tracks its upstream explicitly, branch "diverged" defaults to "origin/diverged"
+	repoConf, err := repository.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repoConf.Branches["behind"] = &gitconfig.Branch{Name: "behind", Remote: git.DefaultRemoteName, Merge: plumbing.NewBranchReferenceName("behind")}
+	if err := repository.Storer.SetConfig(repoConf); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, hash := range map[string]plumbing.Hash{"behind": base, "diverged": head.Hash()} {
+		if err := repository.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diverged, err := fetchRepository(repository, configfile.FetchPolicyTracking, &git.FetchOptions{RemoteName: git.DefaultRemoteName})
+	if err != nil {
+		t.Fatalf("fetchRepository() failed: %v", err)
+	}
+
+	if want := []string{"diverged"}; !slices.Equal(diverged, want) {
+		t.Errorf("fetchRepository() failed: got diverged: %v, want: %v", diverged, want)
+	}
+
+	for name, want := range map[plumbing.ReferenceName]plumbing.Hash{
+		plumbing.HEAD: head.Hash(),
+		plumbing.NewBranchReferenceName("behind"):                          remote,
+		plumbing.NewBranchReferenceName("diverged"):                        head.Hash(),
+		plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "behind"):   remote,
+		plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "diverged"): remote,
+	} {
+		ref, err := repository.Reference(name, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ref.Hash() != want {
+			t.Errorf("fetchRepository() failed: got %s: %s, want: %s", name, ref.Hash(), want)
+		}
+	}
+}
+
+func TestStashChanges(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		remote  map[string]string
+		want    map[string]string
+		wantErr error
+	}{
+		{"test#1", map[string]string{"d.txt": "d\n"}, map[string]string{"a.txt": "stashed\n", "c.txt": "c\n", "d.txt": "d\n"}, nil},
+		{"test#2", map[string]string{"a.txt": "remote\n"}, map[string]string{"a.txt": "remote\n", "c.txt": "c\n"}, errConflict},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repository, workTree, remote := setupDivergedRepository(t, nil, tt.remote)
+			fs := workTree.Filesystem
+
+			// modified, deleted and untracked files
+			if err := billyutil.WriteFile(fs, "a.txt", []byte("stashed\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := fs.Remove("b.txt"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := billyutil.WriteFile(fs, "c.txt", []byte("c\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := stashChanges(repository, workTree); err != nil {
+				t.Fatalf("stashChanges() failed: %v", err)
+			}
+
+			if repoStatus, err := workTree.Status(); err != nil || !repoStatus.IsClean() {
+				t.Fatalf("stashChanges() failed: got dirty work tree: %v", repoStatus)
+			}
+
+			stash, err := repository.Reference(autoStashRef, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// an unrestored stash is never overwritten
+			if err := billyutil.WriteFile(fs, "a.txt", []byte("other\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := stashChanges(repository, workTree); !errors.Is(err, errConflict) {
+				t.Errorf("stashChanges() failed: got error: %v, want: %v", err, errConflict)
+			}
+
+			if ref, err := repository.Reference(autoStashRef, false); err != nil || ref.Hash() != stash.Hash() {
+				t.Errorf("stashChanges() failed: got stash: %v, want: %s", ref, stash.Hash())
+			}
+
+			// the stash is restored onto the pulled commit
+			if err := workTree.Reset(&git.ResetOptions{Mode: git.HardReset, Commit: remote}); err != nil {
+				t.Fatal(err)
+			}
+
+			err = restoreStash(repository, workTree)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("restoreStash() failed: got error: %v, want: %v", err, tt.wantErr)
+			}
+
+			if _, err := repository.Reference(autoStashRef, false); (err == nil) != (tt.wantErr != nil) {
+				t.Errorf("restoreStash() failed: got stash error: %v", err)
+			}
+
+			files, err := fs.ReadDir("/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			for _, file := range files {
+				if !file.IsDir() {
+					got[file.Name()] = readFile(t, workTree, file.Name())
+				}
+			}
+
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("restoreStash() failed: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -32,14 +32,10 @@ func pushOperation(_ pool.WorkUnit, args operationContext) {
 
 	logger := loggerEntry.WithField("command", "push").WithField("repository", repo.Directory)
 
-	conf.AuthenticateURL(&repo.URL)
-	conf.AuthenticateURL(&repo.ParentURL)
-	logger.Debugf("Authenticated: URL: %t, ParentURL: %t", repo.URL != "", repo.ParentURL != "")
-
 	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
 
 	logger.Debug("Pushing to remote")
-	if err := pushRepository(repo, status); err != nil {
+	if err := pushRepository(conf, repo, status); err != nil {
 		logger.Debugf("Failed to push: %v", err)
 		return
 	}
@@ -48,13 +44,19 @@ func pushOperation(_ pool.WorkUnit, args operationContext) {
 }
 
 // Push local repository.
-func pushRepository(repo configfile.Repository, status *operationStatus) error {
+func pushRepository(conf *configfile.Configuration, repo configfile.Repository, status *operationStatus) error {
 	repository, err := openRepository(repo, status)
 	if err != nil {
 		return fmt.Errorf("repository %s: %w", repo.Directory, err)
 	}
 
-	switch err := repository.Push(&git.PushOptions{}); {
+	auth, err := getRemoteAuthMethod(conf, repo, repository, git.DefaultRemoteName)
+	if err != nil {
+		status.appendRow(repo.Directory, err)
+		return fmt.Errorf("repository %s: %w", repo.Directory, err)
+	}
+
+	switch err := repository.Push(&git.PushOptions{Auth: auth}); {
 
 	case
 		errors.Is(err, transport.ErrAuthenticationRequired),
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	color "github.com/fatih/color"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	restclient "github.com/sarumaj/gh-gr/v2/pkg/restclient"
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	supererrors "github.com/sarumaj/go-super/errors"
+	logrus "github.com/sirupsen/logrus"
+	cobra "github.com/spf13/cobra"
+)
+
+// Supported output formats of the rate-limit command.
+const (
+	rateLimitFormatTable = "table"
+	rateLimitFormatJSON  = "json"
+)
+
+// rateLimitFlags represents the flags for rate-limit command
+var rateLimitFlags struct {
+	formatOption string
+}
+
+// rateLimitBucket represents the quota of a single API resource.
+type rateLimitBucket struct {
+	Remaining int       `json:"remaining"`
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	Share     float64   `json:"share"` // Share of the quota used (0-1)
+	Reset     time.Time `json:"reset"`
+}
+
+// rateLimitEntry represents the quotas of an account.
+type rateLimitEntry struct {
+	Host      string                     `json:"host"`
+	Account   string                     `json:"account,omitempty"`
+	Resources map[string]rateLimitBucket `json:"resources,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+}
+
+// rateLimitCmd represents the rate-limit command
+var rateLimitCmd = func() *cobra.Command {
+	rateLimitCmd := &cobra.Command{
+		Use:   "rate-limit",
+		Short: "Display API quotas of configured hosts",
+		Long: "Display API quotas of configured hosts.\n\n" +
+			"Quotas of the core, search, graphql and code search APIs are displayed for every configured token, " +
+			"including the remaining requests, the limit, the share used and the time of the reset.\n" +
+			"Requesting the quotas does not count against them.",
+		Example: "gh gr rate-limit --format json",
+		Args:    cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			c := util.Console()
+			if !configfile.ConfigurationExists() {
+				util.PrintlnAndExit("%s", c.CheckColors(color.RedString, configfile.ConfigNotFound))
+			}
+
+			if !slices.Contains([]string{rateLimitFormatTable, rateLimitFormatJSON}, rateLimitFlags.formatOption) {
+				util.PrintlnAndExit("%s", c.CheckColors(color.RedString, "Unsupported format: %q", rateLimitFlags.formatOption))
+			}
+
+			entries := getRateLimits(configfile.Load())
+
+			if rateLimitFlags.formatOption == rateLimitFormatJSON {
+				raw := supererrors.ExceptFn(supererrors.W(json.MarshalIndent(entries, "", "  ")))
+				_ = supererrors.ExceptFn(supererrors.W(fmt.Fprintln(c.Stdout(), string(raw))))
+				return
+			}
+
+			printRateLimits(entries)
+		},
+	}
+
+	flags := rateLimitCmd.Flags()
+	supportedFormats := strings.Join([]string{rateLimitFormatTable, rateLimitFormatJSON}, ", ")
+	flags.StringVarP(&rateLimitFlags.formatOption, "format", "f", rateLimitFormatTable, fmt.Sprintf("Change output format, supported formats: [%s]", supportedFormats))
+
+	return rateLimitCmd
+}()
+
+// getRateLimits retrieves the quotas of all configured tokens.
+func getRateLimits(conf *configfile.Configuration) []rateLimitEntry {
+	logger := loggerEntry.WithField("command", "rate-limit")
+
+	tokens := conf.GetTokens()
+	logger.Debugf("Retrieved tokens: %d", len(tokens))
+
+	var entries []rateLimitEntry
+	for _, account := range slices.Sorted(maps.Keys(tokens)) {
+		// tokens are mapped to <username>@<host>, or to <host> only if the account is not known yet
+		login, host, found := strings.Cut(account, "@")
+		if !found {
+			login, host = "", account
+		}
+
+		// quotas are specific to the GitHub API
+		if profile, ok := conf.Profiles.ForHost(host); ok && profile.GetProvider() != configfile.ProviderGitHub {
+			logger.Debugf("Skipping %s of provider %s", account, profile.GetProvider())
+			continue
+		}
+
+		entry := rateLimitEntry{Host: host, Account: login}
+		rate, err := fetchRateLimit(conf, restclient.ClientOptions{
+			AuthToken:   tokens[account],
+			Log:         logger.WriterLevel(logrus.DebugLevel),
+			LogColorize: util.Console().ColorsEnabled(),
+			Host:        host,
+		})
+		if err != nil {
+			logger.Warnf("Failed to retrieve rate limit of %s: %v", account, err)
+			entry.Error = err.Error()
+		} else {
+			entry.Resources = map[string]rateLimitBucket{
+				"core":        newRateLimitBucket(rate.Resources.Core),
+				"search":      newRateLimitBucket(rate.Resources.Search),
+				"graphql":     newRateLimitBucket(rate.Resources.Graphql),
+				"code_search": newRateLimitBucket(rate.Resources.CodeSearch),
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// fetchRateLimit retrieves the quotas of a single token.
+// Exhausted quotas are retrieved rather than waited for or exited on.
+// The quotas are retrieved upon creation of the client, they are not requested again.
+func fetchRateLimit(conf *configfile.Configuration, options restclient.ClientOptions) (*resources.RateLimit, error) {
+	client, err := restclient.NewRESTClient(conf, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.RateLimit(), nil
+}
+
+// newRateLimitBucket converts the quota of an API resource.
+func newRateLimitBucket(rate resources.Rate) rateLimitBucket {
+	bucket := rateLimitBucket{
+		Remaining: rate.Remaining,
+		Limit:     rate.Limit,
+		Used:      rate.Limit - rate.Remaining,
+		Reset:     time.Unix(rate.Reset, 0),
+	}
+
+	if bucket.Limit > 0 {
+		bucket.Share = float64(bucket.Used) / float64(bucket.Limit)
+	}
+
+	return bucket
+}
+
+// printRateLimits prints the quotas in tabular form.
+// Exhausted quotas and failing hosts are highlighted.
+func printRateLimits(entries []rateLimitEntry) {
+	printer := util.NewTablePrinter().SetHeader("Host", "Account", "Resource", "Remaining", "Limit", "Used", "Reset")
+	for _, entry := range entries {
+		if entry.Error != "" {
+			// rows have as many fields as the header
+			printer.AddRowField(entry.Host).AddRowField(entry.Account).AddRowField(entry.Error, color.FgRed)
+			for range 4 {
+				printer.AddRowField("")
+			}
+
+			printer.EndRow()
+			continue
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(entry.Resources)) {
+			bucket := entry.Resources[name]
+
+			remaining := color.FgGreen
+			if bucket.Remaining == 0 {
+				remaining = color.FgRed
+			}
+
+			printer.
+				AddRowField(entry.Host).
+				AddRowField(entry.Account).
+				AddRowField(name).
+				AddRowField(fmt.Sprint(bucket.Remaining), remaining).
+				AddRowField(fmt.Sprint(bucket.Limit)).
+				AddRowField(fmt.Sprintf("%.1f%%", bucket.Share*100)).
+				AddRowField(fmt.Sprintf("%s (in %s)", bucket.Reset.Format(time.DateTime), time.Until(bucket.Reset).Round(time.Second))).
+				EndRow()
+		}
+	}
+
+	printer.Align().Print()
+}
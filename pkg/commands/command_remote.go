@@ -0,0 +1,22 @@
+package commands
+
+import (
+	cobra "github.com/spf13/cobra"
+)
+
+// remoteCmd represents the remote command
+var remoteCmd = func() *cobra.Command {
+	remoteCmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Manage remotes of all repositories",
+		Long: "Manage remotes of all repositories.\n\n" +
+			"Credentials are supplied at runtime and are never stored in the remote URLs of local repositories.\n" +
+			"Remotes can be switched between HTTPS and SSH transport.",
+		Example: "gh gr remote sanitize",
+		Args:    cobra.NoArgs,
+	}
+
+	remoteCmd.AddCommand(remoteSanitizeCmd, remoteSwitchCmd)
+
+	return remoteCmd
+}()
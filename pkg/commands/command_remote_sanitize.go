@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+
+	git "github.com/go-git/go-git/v5"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	cobra "github.com/spf13/cobra"
+	pool "gopkg.in/go-playground/pool.v3"
+)
+
+// remoteSanitizeFlags represents the flags for remote sanitize command
+var remoteSanitizeFlags struct {
+	filters []string
+}
+
+// remoteSanitizeCmd represents the remote sanitize command
+var remoteSanitizeCmd = func() *cobra.Command {
+	remoteSanitizeCmd := &cobra.Command{
+		Use:   "sanitize",
+		Short: "Remove credentials from remote URLs",
+		Long: "Remove credentials from remote URLs.\n\n" +
+			"Previous versions stored personal access tokens in the remote and submodule URLs of local repositories.\n" +
+			"This command removes them from existing clones (including their submodules).\n" +
+			"The number of sanitized URLs is listed for each repository.\n" +
+			"Supports filtering local repositories using glob match (see \"gr view --help\").",
+		Example: "gh gr remote sanitize",
+		Args:    cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			operationLoop[configfile.Repository](remoteSanitizeOperation, "Sanitize", operationContextMap{
+				"filters": remoteSanitizeFlags.filters,
+				"headers": []string{"Repository", "Status", "Sanitized"},
+			})
+		},
+	}
+
+	flags := remoteSanitizeCmd.Flags()
+	flags.StringArrayVarP(&remoteSanitizeFlags.filters, "match", "m", []string{}, "Glob pattern(s) to filter repositories")
+
+	return remoteSanitizeCmd
+}()
+
+// Remove credentials from remote URLs of local repository.
+func remoteSanitizeOperation(_ pool.WorkUnit, args operationContext) {
+	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
+	repo := unwrapOperationContext[configfile.Repository](args, "object")
+	status := unwrapOperationContext[*operationStatus](args, "status")
+
+	logger := loggerEntry.WithField("command", "remote sanitize").WithField("repository", repo.Directory)
+
+	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
+
+	if !util.PathExists(repo.Directory) {
+		logger.Debug("Local repository does not exist")
+		status.appendRow(repo.Directory, fmt.Errorf("absent"))
+		return
+	}
+
+	repository, err := openRepository(repo, status)
+	if err != nil {
+		logger.Debugf("Failed to open: %v", err)
+		return
+	}
+
+	count, err := sanitizeRepository(conf, repository)
+	if err != nil {
+		logger.Debugf("Failed to sanitize: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	logger.Debugf("Sanitized URLs: %d", count)
+	if count > 0 {
+		status.appendRow(repo.Directory, "sanitized", count)
+	} else {
+		status.appendRow(repo.Directory, "clean", count)
+	}
+}
+
+// sanitizeRepository removes credentials from remote URLs of given repository and its submodules.
+// The number of modified URLs is returned.
+func sanitizeRepository(conf *configfile.Configuration, repository *git.Repository) (int, error) {
+	repoConf, err := repository.Config()
+	if err != nil {
+		return 0, err
+	}
+
+	count := generalizeRemoteURLs(conf, repoConf)
+	if count > 0 {
+		if err := repository.Storer.SetConfig(repoConf); err != nil {
+			return 0, err
+		}
+	}
+
+	workTree, err := repository.Worktree()
+	if err != nil {
+		return 0, err
+	}
+
+	submodules, err := workTree.Submodules()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, submodule := range submodules {
+		subRepository, err := submodule.Repository()
+		if err != nil {
+			// submodule has not been initialized yet
+			continue
+		}
+
+		subCount, err := sanitizeRepository(conf, subRepository)
+		if err != nil {
+			return 0, fmt.Errorf("submodule %s: %w", submodule.Config().Path, err)
+		}
+
+		count += subCount
+	}
+
+	return count, nil
+}
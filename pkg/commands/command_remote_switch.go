@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+
+	color "github.com/fatih/color"
+	git "github.com/go-git/go-git/v5"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	cobra "github.com/spf13/cobra"
+	pool "gopkg.in/go-playground/pool.v3"
+)
+
+// remoteSwitchFlags represents the flags for remote switch command
+var remoteSwitchFlags struct {
+	filters   []string
+	transport string
+}
+
+// Supported transports.
+var transports = []string{configfile.TransportHTTPS, configfile.TransportSSH}
+
+// remoteSwitchCmd represents the remote switch command
+var remoteSwitchCmd = func() *cobra.Command {
+	remoteSwitchCmd := &cobra.Command{
+		Use:   "switch",
+		Short: "Switch remotes between HTTPS and SSH",
+		Long: "Switch remotes between HTTPS and SSH.\n\n" +
+			"The URLs of \"origin\" (and \"upstream\" for forks) are set according to the configured transport " +
+			"(see \"gr init --help\"), unless a transport is given explicitly.\n" +
+			"SSH URLs are retrieved by \"gr update\", configurations created by previous versions have to be updated first.\n" +
+			"Supports filtering local repositories using glob match (see \"gr view --help\").",
+		Example: "gh gr remote switch --transport ssh",
+		Args:    cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			if remoteSwitchFlags.transport != "" && !slices.Contains(transports, remoteSwitchFlags.transport) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported transport: %q", remoteSwitchFlags.transport))
+			}
+
+			operationLoop[configfile.Repository](remoteSwitchOperation, "Switch", operationContextMap{
+				"filters":   remoteSwitchFlags.filters,
+				"transport": remoteSwitchFlags.transport,
+				"headers":   []string{"Repository", "Status", "Transport"},
+			})
+		},
+	}
+
+	flags := remoteSwitchCmd.Flags()
+	flags.StringArrayVarP(&remoteSwitchFlags.filters, "match", "m", []string{}, "Glob pattern(s) to filter repositories")
+	flags.StringVar(&remoteSwitchFlags.transport, "transport", "", fmt.Sprintf("Overwrite configured transport (%q or %q)", configfile.TransportHTTPS, configfile.TransportSSH))
+
+	return remoteSwitchCmd
+}()
+
+// Switch remote URLs of local repository to the configured transport.
+func remoteSwitchOperation(_ pool.WorkUnit, args operationContext) {
+	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
+	repo := unwrapOperationContext[configfile.Repository](args, "object")
+	status := unwrapOperationContext[*operationStatus](args, "status")
+	transportName := unwrapOperationContext[string](args, "transport")
+
+	logger := loggerEntry.WithField("command", "remote switch").WithField("repository", repo.Directory)
+
+	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
+
+	if transportName == "" {
+		transportName = conf.GetTransport(repo)
+	}
+
+	var originURL, upstreamURL string
+	switch transportName {
+
+	case configfile.TransportHTTPS:
+		originURL, upstreamURL = repo.URL, repo.ParentURL
+
+	case configfile.TransportSSH:
+		originURL, upstreamURL = repo.SSHURL, repo.ParentSSHURL
+
+	default:
+		status.appendRow(repo.Directory, fmt.Errorf("unsupported transport: %q", transportName), transportName)
+		return
+
+	}
+
+	if originURL == "" {
+		logger.Debugf("No %s URL configured", transportName)
+		status.appendRow(repo.Directory, fmt.Errorf("unknown URL (run update)"), transportName)
+		return
+	}
+
+	if !util.PathExists(repo.Directory) {
+		logger.Debug("Local repository does not exist")
+		status.appendRow(repo.Directory, fmt.Errorf("absent"), transportName)
+		return
+	}
+
+	repository, err := openRepository(repo, status)
+	if err != nil {
+		logger.Debugf("Failed to open: %v", err)
+		return
+	}
+
+	repoConf, err := repository.Config()
+	if err != nil {
+		logger.Debugf("Failed to retrieve repo config: %v", err)
+		status.appendRow(repo.Directory, err, transportName)
+		return
+	}
+
+	var switched bool
+	for remoteName, remoteURL := range map[string]string{git.DefaultRemoteName: originURL, "upstream": upstreamURL} {
+		remote, ok := repoConf.Remotes[remoteName]
+		if !ok || remoteURL == "" || (len(remote.URLs) == 1 && remote.URLs[0] == remoteURL) {
+			continue
+		}
+
+		logger.Debugf("Switching %s to %s", remoteName, remoteURL)
+		remote.URLs = []string{remoteURL}
+		switched = true
+	}
+
+	if !switched {
+		status.appendRow(repo.Directory, "unchanged", transportName)
+		return
+	}
+
+	if err := repoConf.Validate(); err != nil {
+		logger.Debugf("Invalid repo config: %v", err)
+		status.appendRow(repo.Directory, err, transportName)
+		return
+	}
+
+	if err := repository.Storer.SetConfig(repoConf); err != nil {
+		logger.Debugf("Failed to update repo config: %v", err)
+		status.appendRow(repo.Directory, err, transportName)
+		return
+	}
+
+	status.appendRow(repo.Directory, "switched", transportName)
+}
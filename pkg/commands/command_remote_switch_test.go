@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+func TestRemoteSwitchOperation(t *testing.T) {
+	repo := configfile.Repository{
+		Directory:    "local",
+		URL:          "https://github.com/octocat/hello.git",
+		SSHURL:       "git@github.com:octocat/hello.git",
+		ParentURL:    "https://github.com/parent/hello.git",
+		ParentSSHURL: "git@github.com:parent/hello.git",
+	}
+
+	type args struct {
+		transport  string
+		configured string
+		remotes    map[string]string
+		repo       configfile.Repository
+	}
+
+	for _, tt := range []struct {
+		name        string
+		args        args
+		want        string
+		wantRemotes map[string]string
+	}{
+		{"test#1", args{"ssh", "", map[string]string{"origin": repo.URL, "upstream": repo.ParentURL}, repo},
+			"local switched ssh", map[string]string{"origin": repo.SSHURL, "upstream": repo.ParentSSHURL}},
+		{"test#2", args{"", "ssh", map[string]string{"origin": repo.URL}, repo},
+			"local switched ssh", map[string]string{"origin": repo.SSHURL}},
+		{"test#3", args{"https", "ssh", map[string]string{"origin": repo.SSHURL, "upstream": repo.ParentSSHURL}, repo},
+			"local switched https", map[string]string{"origin": repo.URL, "upstream": repo.ParentURL}},
+		{"test#4", args{"", "", map[string]string{"origin": repo.URL, "upstream": repo.ParentURL}, repo},
+			"local unchanged https", map[string]string{"origin": repo.URL, "upstream": repo.ParentURL}},
+		{"test#5", args{"ssh", "", map[string]string{"origin": repo.URL}, configfile.Repository{Directory: "local", URL: repo.URL}},
+			"local unknown URL (run update) ssh", map[string]string{"origin": repo.URL}},
+		{"test#6", args{"ftp", "", map[string]string{"origin": repo.URL}, repo},
+			"local unsupported transport: \"ftp\" ftp", map[string]string{"origin": repo.URL}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repository, err := git.PlainInit(filepath.Join(dir, "local"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, url := range tt.args.remotes {
+				if _, err := repository.CreateRemote(&gitconfig.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			status := newOperationStatus()
+			remoteSwitchOperation(nil, newOperationContext(operationContextMap{
+				"conf":      &configfile.Configuration{AbsoluteDirectoryPath: dir, Transport: tt.args.configured},
+				"object":    tt.args.repo,
+				"status":    status,
+				"transport": tt.args.transport,
+			}))
+
+			if got := strings.Fields(status.Sprint()); !slices.Equal(got, strings.Fields(tt.want)) {
+				t.Errorf("remoteSwitchOperation() failed: got: %v, want: %v", got, tt.want)
+			}
+
+			repoConf, err := repository.Config()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			for name, remote := range repoConf.Remotes {
+				got[name] = strings.Join(remote.URLs, " ")
+			}
+
+			if !maps.Equal(got, tt.wantRemotes) {
+				t.Errorf("remoteSwitchOperation() failed: got remotes: %v, want: %v", got, tt.wantRemotes)
+			}
+		})
+	}
+}
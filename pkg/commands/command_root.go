@@ -4,6 +4,7 @@ import (
 	"time"
 
 	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	restclient "github.com/sarumaj/gh-gr/v2/pkg/restclient"
 	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	supererrors "github.com/sarumaj/go-super/errors"
 	logrus "github.com/sirupsen/logrus"
@@ -16,7 +17,8 @@ var configFlags = &configfile.Configuration{}
 // globalNonPersistentFlags is a global variable holding global non-persistent flags,
 // which are not stored in configuration file
 var globalNonPersistentFlags struct {
-	retry bool
+	noCache bool
+	retry   bool
 }
 
 // loggerEntry is a global variable holding logger entry at package level
@@ -41,18 +43,25 @@ var rootCmd = func() *cobra.Command {
 				logger.SetLevel(logrus.DebugLevel)
 			}
 
+			if err := configFlags.Hosts.InstallGitTransport(); err != nil {
+				logger.Warnf("Failed to apply network settings of hosts: %v", err)
+			}
+
 			logger.Debugf("Version: %s, build date: %s, executable path: %s", versionFlags.internalVersion, versionFlags.internalBuildDate, util.GetExecutablePath())
 			logger.Debug("Running in verbose mode")
+
+			restclient.BypassCache(globalNonPersistentFlags.noCache)
 		},
 		Version: versionFlags.internalVersion,
 	}
 
 	flags := cmd.PersistentFlags()
 	flags.UintVarP(&configFlags.Concurrency, "concurrency", "c", util.GetIdealConcurrency(), "Concurrency for concurrent jobs")
+	flags.BoolVar(&globalNonPersistentFlags.noCache, "no-cache", false, "Bypass the cache of API responses")
 	flags.BoolVarP(&globalNonPersistentFlags.retry, "retry", "r", false, "Retry rate-limited operations")
 	flags.DurationVarP(&configFlags.Timeout, "timeout", "t", 10*time.Minute, "Set timeout for long running jobs")
 
-	cmd.AddCommand(cleanupCmd, editCmd, exportCmd, initCmd, importCmd, pullCmd, pushCmd, prCmd, removeCmd, statusCmd, updateCmd, versionCmd, viewCmd)
+	cmd.AddCommand(cacheCmd, cleanupCmd, editCmd, execCmd, exportCmd, fetchCmd, initCmd, importCmd, pullCmd, pushCmd, prCmd, rateLimitCmd, remoteCmd, removeCmd, statusCmd, syncUpstreamCmd, updateCmd, versionCmd, viewCmd)
 
 	return cmd
 }()
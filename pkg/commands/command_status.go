@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	git "github.com/go-git/go-git/v5"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
 	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
 	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	cobra "github.com/spf13/cobra"
@@ -12,7 +15,9 @@ import (
 
 // statusFlags represents flags for status command
 var statusFlags struct {
-	reset bool
+	allBranches bool
+	offline     bool
+	reset       bool
 }
 
 // statusCmd represents the status command
@@ -21,14 +26,37 @@ var statusCmd = func() *cobra.Command {
 		Use:   "status",
 		Short: "Show status for all repositories",
 		Long: "Show status for all repositories.\n\n" +
+			"The number of commits the current branch is ahead or behind the remote default branch is listed.\n" +
+			"Optionally, local branches with unpushed commits or without upstream can be listed.\n" +
+			"In offline mode, the remote state is determined from the locally stored remote-tracking references " +
+			"and the time of the last fetch is listed (see \"gr fetch --help\").\n" +
 			"Additionally, untracked directories will be listed.",
 		Example: "gh gr status",
-		Run: func(*cobra.Command, []string) {
+		Run: func(cmd *cobra.Command, _ []string) {
+			offline := configFlags.OfflineStatus
+			if cmd.Flags().Changed("offline") {
+				offline = statusFlags.offline
+			}
+
+			headers := []string{"Repository", "Branch", "Status", "Remote", "Ahead", "Behind"}
+			if offline {
+				headers = append(headers, "Fetched")
+			}
+
+			branches := newOperationStatus()
 			operationLoop[configfile.Repository](statusOperation, "Check", operationContextMap{
-				"reset":   statusFlags.reset,
-				"headers": []string{"Repository", "Branch", "Status", "Remote"},
+				"allBranches": statusFlags.allBranches,
+				"branches":    branches,
+				"offline":     offline,
+				"reset":       statusFlags.reset,
+				"headers":     headers,
 			})
 
+			if statusFlags.allBranches {
+				branches.SetHeader("Repository", "Branch", "Upstream", "Ahead", "Status")
+				branches.Sort().Align().Print()
+			}
+
 			conf := configfile.Load()
 			status := newOperationStatus()
 
@@ -42,6 +70,8 @@ var statusCmd = func() *cobra.Command {
 	}
 
 	flags := statusCmd.Flags()
+	flags.BoolVar(&statusFlags.allBranches, "all-branches", false, "List local branches with unpushed commits or without upstream")
+	flags.BoolVar(&statusFlags.offline, "offline", false, "Compare against locally stored remote-tracking references instead of querying the remote (overwrites configured default)")
 	flags.BoolVar(&statusFlags.reset, "reset-all", false, "Perform hard reset against remote for each dirty local repository "+
 		"(it will discard all not staged and not committed changes)")
 
@@ -54,13 +84,12 @@ func statusOperation(_ pool.WorkUnit, args operationContext) {
 	repo := unwrapOperationContext[configfile.Repository](args, "object")
 	status := unwrapOperationContext[*operationStatus](args, "status")
 	reset := unwrapOperationContext[bool](args, "reset")
+	allBranches := unwrapOperationContext[bool](args, "allBranches")
+	offline := unwrapOperationContext[bool](args, "offline")
+	branches := unwrapOperationContext[*operationStatus](args, "branches")
 
 	logger := loggerEntry.WithField("command", "status").WithField("repository", repo.Directory)
 
-	conf.AuthenticateURL(&repo.URL)
-	conf.AuthenticateURL(&repo.ParentURL)
-	logger.Debugf("Authenticated: URL: %t, ParentURL: %t", repo.URL != "", repo.ParentURL != "")
-
 	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
 
 	var ret []any
@@ -120,32 +149,196 @@ func statusOperation(_ pool.WorkUnit, args operationContext) {
 		ret = append(ret, fmt.Errorf("dirty"))
 	}
 
-	remote, err := repository.Remote(git.DefaultRemoteName)
-	if err != nil {
-		logger.Debugf("Failed to retrieve remote name: %v", err)
-		status.appendRow(repo.Directory, err)
-		return
+	if offline {
+		state, err := describeRemoteTrackingState(repository, head.Hash(), repo.Branch)
+		if err != nil {
+			logger.Debugf("Failed to compare with remote-tracking reference: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		fetched, err := getLastFetchTime(repository)
+		if err != nil {
+			logger.Debugf("Failed to retrieve time of last fetch: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		logger.Debugf("Repository %s: %v, fetched: %v", repo.Directory, state, fetched)
+		ret = append(ret, state...)
+		if fetched.IsZero() {
+			ret = append(ret, fmt.Errorf("never"))
+		} else {
+			ret = append(ret, fetched.Format(time.DateTime))
+		}
+
+	} else {
+		remote, err := repository.Remote(git.DefaultRemoteName)
+		if err != nil {
+			logger.Debugf("Failed to retrieve remote name: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		auth, err := getRemoteAuthMethod(conf, repo, repository, git.DefaultRemoteName)
+		if err != nil {
+			logger.Debugf("Failed to retrieve authentication: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		remoteRef, err := remote.List(&git.ListOptions{Auth: auth})
+		if err != nil {
+			logger.Debugf("Failed to retrieve remote references: %v", err)
+			status.appendRow(repo.Directory, err)
+			return
+		}
+
+		for _, r := range remoteRef {
+			if r.Name().String() == "refs/heads/"+repo.Branch {
+				state, err := describeRemoteState(repository, head.Hash(), r.Hash(), repo.Branch)
+				if err != nil {
+					logger.Debugf("Failed to compare with remote: %v", err)
+					status.appendRow(repo.Directory, err)
+					return
+				}
+
+				logger.Debugf("Repository %s: %v", repo.Directory, state)
+				ret = append(ret, state...)
+				break
+			}
+		}
+
 	}
 
-	remoteRef, err := remote.List(&git.ListOptions{})
-	if err != nil {
-		logger.Debugf("Failed to retrieve remote references: %v", err)
-		status.appendRow(repo.Directory, err)
-		return
+	if allBranches {
+		if err := listBranchStates(repository, repo, branches); err != nil {
+			logger.Debugf("Failed to list branches: %v", err)
+			branches.appendRow(repo.Directory, "", "", "", err)
+		}
 	}
 
-	for _, r := range remoteRef {
-		if r.Name().String() == "refs/heads/"+repo.Branch {
-			if r.Hash() == head.Hash() {
-				logger.Debugf("Repository %s: latest", repo.Directory)
-				ret = append(ret, "latest")
-			} else {
-				logger.Debugf("Repository %s: stale", repo.Directory)
-				ret = append(ret, fmt.Errorf("stale"))
+	status.appendRow(repo.Directory, ret...)
+}
+
+// describeRemoteState compares local commit with remote one.
+// It results in a remote state ("latest", "ahead", "behind", "diverged" or "stale")
+// followed by the number of commits ahead and behind.
+// If the remote commit has not been fetched yet, the commits are counted against the remote-tracking reference of given branch
+// and the state is reported as stale relative to it.
+func describeRemoteState(repository *git.Repository, local, remote plumbing.Hash, branch string) ([]any, error) {
+	if local == remote {
+		return []any{"latest", 0, 0}, nil
+	}
+
+	if _, err := repository.CommitObject(remote); errors.Is(err, plumbing.ErrObjectNotFound) {
+		trackingName := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch)
+		switch tracking, err := repository.Reference(trackingName, true); {
+
+		case errors.Is(err, plumbing.ErrReferenceNotFound):
+			return []any{fmt.Errorf("stale"), "?", "?"}, nil
+
+		case err != nil:
+			return nil, err
+
+		default:
+			state, err := compareCommits(repository, local, tracking.Hash())
+			if err != nil {
+				return nil, err
 			}
-			break
+
+			state[0] = fmt.Errorf("stale: %v relative to %s", state[0], trackingName.Short())
+			return state, nil
+
 		}
 	}
 
-	status.appendRow(repo.Directory, ret...)
+	return compareCommits(repository, local, remote)
+}
+
+// compareCommits compares local commit with remote one, both of which have to be available locally.
+// It results in a remote state ("latest", "ahead", "behind" or "diverged") followed by the number of commits ahead and behind.
+func compareCommits(repository *git.Repository, local, remote plumbing.Hash) ([]any, error) {
+	ahead, behind, err := countAheadBehind(repository, local, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+
+	case ahead > 0 && behind > 0:
+		return []any{fmt.Errorf("diverged"), ahead, behind}, nil
+
+	case ahead > 0:
+		return []any{fmt.Errorf("ahead"), ahead, behind}, nil
+
+	case behind > 0:
+		return []any{fmt.Errorf("behind"), ahead, behind}, nil
+
+	default:
+		return []any{"latest", ahead, behind}, nil
+
+	}
+}
+
+// describeRemoteTrackingState compares local commit with the locally stored remote-tracking reference of given branch.
+// No network requests are issued, hence the result is only as recent as the last fetch.
+func describeRemoteTrackingState(repository *git.Repository, local plumbing.Hash, branch string) ([]any, error) {
+	switch ref, err := repository.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true); {
+
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		return []any{fmt.Errorf("unfetched"), "?", "?"}, nil
+
+	case err != nil:
+		return nil, err
+
+	default:
+		return describeRemoteState(repository, local, ref.Hash(), branch)
+
+	}
+}
+
+// listBranchStates lists local branches with unpushed commits or without upstream.
+func listBranchStates(repository *git.Repository, repo configfile.Repository, branches *operationStatus) error {
+	repoConf, err := repository.Config()
+	if err != nil {
+		return err
+	}
+
+	iter, err := repository.Branches()
+	if err != nil {
+		return err
+	}
+
+	return iter.ForEach(func(branch *plumbing.Reference) error {
+		name := branch.Name().Short()
+		upstreamName, configured := getUpstreamReferenceName(repoConf, name)
+		if !configured {
+			branches.appendRow(repo.Directory, name, "", "", fmt.Errorf("no upstream"))
+			return nil
+		}
+
+		upstream, err := repository.Reference(upstreamName, true)
+		switch {
+
+		case errors.Is(err, plumbing.ErrReferenceNotFound):
+			branches.appendRow(repo.Directory, name, upstreamName.Short(), "", fmt.Errorf("upstream gone"))
+			return nil
+
+		case err != nil:
+			return err
+
+		}
+
+		ahead, _, err := countAheadBehind(repository, branch.Hash(), upstream.Hash())
+		if err != nil {
+			return err
+		}
+
+		if ahead > 0 {
+			branches.appendRow(repo.Directory, name, upstreamName.Short(), ahead, fmt.Errorf("unpushed"))
+		}
+
+		return nil
+	})
 }
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+func TestStatusOperationOffline(t *testing.T) {
+	fetched := time.Date(2024, time.February, 29, 12, 30, 0, 0, time.Local)
+
+	for _, tt := range []struct {
+		name      string
+		tracking  string
+		fetchHead bool
+		want      []string
+	}{
+		{"test#1", "", false, []string{"local", "master", "clean", "unfetched", "?", "?", "never"}},
+		{"test#2", "local", false, []string{"local", "master", "clean", "latest", "0", "0", "never"}},
+		{"test#3", "remote", false, []string{"local", "master", "clean", "diverged", "1", "1", "never"}},
+		{"test#4", "remote", true, []string{"local", "master", "clean", "diverged", "1", "1", "2024-02-29", "12:30:00"}},
+		{"test#5", "base", true, []string{"local", "master", "clean", "ahead", "1", "0", "2024-02-29", "12:30:00"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repository, err := git.PlainInit(filepath.Join(dir, "local"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			workTree, err := repository.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			commits := map[string]plumbing.Hash{"base": commitFiles(t, workTree, "base", map[string]string{"a.txt": "a\n"})}
+			commits["remote"] = commitFiles(t, workTree, "remote", map[string]string{"b.txt": "remote\n"})
+			if err := workTree.Reset(&git.ResetOptions{Mode: git.HardReset, Commit: commits["base"]}); err != nil {
+				t.Fatal(err)
+			}
+
+			commits["local"] = commitFiles(t, workTree, "local", map[string]string{"a.txt": "local\n"})
+			if tt.tracking != "" {
+				ref := plumbing.NewHashReference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, "master"), commits[tt.tracking])
+				if err := repository.Storer.SetReference(ref); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.fetchHead {
+				path := filepath.Join(dir, "local", git.GitDirName, fetchHeadFile)
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.Chtimes(path, fetched, fetched); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the repository has no remote, which is never queried in offline mode
+			status := newOperationStatus()
+			statusOperation(nil, newOperationContext(operationContextMap{
+				"conf":        &configfile.Configuration{AbsoluteDirectoryPath: dir},
+				"object":      configfile.Repository{Directory: "local", Branch: "master"},
+				"status":      status,
+				"reset":       false,
+				"allBranches": false,
+				"offline":     true,
+				"branches":    newOperationStatus(),
+			}))
+
+			if got := strings.Fields(status.Sprint()); !slices.Equal(got, tt.want) {
+				t.Errorf("statusOperation() failed: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
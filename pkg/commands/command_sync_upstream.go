@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+	cobra "github.com/spf13/cobra"
+	pool "gopkg.in/go-playground/pool.v3"
+)
+
+// syncUpstreamFlags represents the flags for sync-upstream command
+var syncUpstreamFlags struct {
+	filters []string
+	push    bool
+}
+
+// syncUpstreamCmd represents the sync-upstream command
+var syncUpstreamCmd = func() *cobra.Command {
+	syncUpstreamCmd := &cobra.Command{
+		Use:   "sync-upstream",
+		Short: "Synchronize forks with their parent repositories",
+		Long: "Synchronize forks with their parent repositories.\n\n" +
+			"For each fork, the remote \"upstream\" is fetched and the default branch of the fork " +
+			"is fast-forwarded to the default branch of the parent repository.\n" +
+			"Forks, which have diverged from their parents, are listed with the number of commits on each side and left untouched.\n" +
+			"Optionally, the synchronized default branch is pushed to \"origin\".\n" +
+			"Supports filtering local repositories using glob match (see \"gr view --help\").",
+		Example: "gh gr sync-upstream --push",
+		Run: func(*cobra.Command, []string) {
+			headers := []string{"Repository", "Branch", "Status", "Ahead", "Behind"}
+			if syncUpstreamFlags.push {
+				headers = append(headers, "Origin")
+			}
+
+			operationLoop[configfile.Repository](syncUpstreamOperation, "Synchronize", operationContextMap{
+				"filters": syncUpstreamFlags.filters,
+				"push":    syncUpstreamFlags.push,
+				"headers": headers,
+			})
+		},
+	}
+
+	flags := syncUpstreamCmd.Flags()
+	flags.StringArrayVarP(&syncUpstreamFlags.filters, "match", "m", []string{}, "Glob pattern(s) to filter repositories")
+	flags.BoolVar(&syncUpstreamFlags.push, "push", false, "Push synchronized default branch to \"origin\"")
+
+	return syncUpstreamCmd
+}()
+
+// fastForwardBranch moves given local branch to given commit.
+// If the branch is checked out, the work tree is updated as well (it must be clean).
+func fastForwardBranch(repository *git.Repository, branch plumbing.ReferenceName, hash plumbing.Hash) error {
+	head, err := repository.Head()
+	if err != nil {
+		return err
+	}
+
+	if head.Name() != branch {
+		return repository.Storer.SetReference(plumbing.NewHashReference(branch, hash))
+	}
+
+	workTree, err := repository.Worktree()
+	if err != nil {
+		return err
+	}
+
+	repoStatus, err := workTree.Status()
+	if err != nil {
+		return err
+	}
+
+	if !repoStatus.IsClean() {
+		return git.ErrWorktreeNotClean
+	}
+
+	return workTree.Reset(&git.ResetOptions{
+		Mode:   git.HardReset,
+		Commit: hash,
+	})
+}
+
+// Synchronize fork with its parent repository.
+func syncUpstreamOperation(_ pool.WorkUnit, args operationContext) {
+	conf := unwrapOperationContext[*configfile.Configuration](args, "conf")
+	repo := unwrapOperationContext[configfile.Repository](args, "object")
+	status := unwrapOperationContext[*operationStatus](args, "status")
+	push := unwrapOperationContext[bool](args, "push")
+
+	logger := loggerEntry.WithField("command", "sync-upstream").WithField("repository", repo.Directory)
+
+	if repo.ParentURL == "" {
+		logger.Debug("Not a fork")
+		return
+	}
+
+	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
+
+	if !util.PathExists(repo.Directory) {
+		logger.Debug("Local repository does not exist")
+		status.appendRow(repo.Directory, fmt.Errorf("absent"))
+		return
+	}
+
+	repository, err := openRepository(repo, status)
+	if err != nil {
+		logger.Debugf("Failed to open: %v", err)
+		return
+	}
+
+	logger.Debug("Overwriting repo config")
+	// remove personal access token from remote URLs
+	if err := updateRepoConfig(conf, nil, repository); err != nil {
+		logger.Debugf("Failed to update repo config: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	if err := ensureUpstreamRemote(repository, conf.GetParentURL(repo)); err != nil {
+		logger.Debugf("Failed to create mirror: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	options, err := newFetchOptions(conf, repo, repository, "upstream")
+	if err != nil {
+		logger.Debugf("Failed to prepare fetch options: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	logger.Debug("Fetching upstream")
+	if _, err := fetchRemote(repository, options); err != nil {
+		logger.Debugf("Failed to fetch upstream: %v", err)
+		status.appendRow(repo.Directory, fmt.Errorf("upstream: %w", err))
+		return
+	}
+
+	parentBranch, err := getRemoteDefaultBranch(repository, "upstream", options.Auth)
+	if err != nil {
+		logger.Debugf("Failed to retrieve default branch of upstream: %v", err)
+		status.appendRow(repo.Directory, fmt.Errorf("upstream: %w", err))
+		return
+	}
+
+	if parentBranch == "" {
+		parentBranch = repo.Branch
+	}
+
+	upstream, err := repository.Reference(plumbing.NewRemoteReferenceName("upstream", parentBranch), true)
+	if err != nil {
+		logger.Debugf("Failed to retrieve upstream branch %s: %v", parentBranch, err)
+		status.appendRow(repo.Directory, fmt.Errorf("upstream/%s: %w", parentBranch, err))
+		return
+	}
+
+	branchName := plumbing.NewBranchReferenceName(repo.Branch)
+	branch, err := repository.Reference(branchName, true)
+	if err != nil {
+		logger.Debugf("Failed to retrieve branch %s: %v", repo.Branch, err)
+		status.appendRow(repo.Directory, fmt.Errorf("%s: %w", repo.Branch, err))
+		return
+	}
+
+	ahead, behind, err := countAheadBehind(repository, branch.Hash(), upstream.Hash())
+	if err != nil {
+		logger.Debugf("Failed to compare with upstream: %v", err)
+		status.appendRow(repo.Directory, err)
+		return
+	}
+
+	var state any
+	switch {
+
+	case ahead > 0 && behind > 0:
+		logger.Debugf("Diverged from upstream: ahead %d, behind %d", ahead, behind)
+		status.appendRow(repo.Directory, repo.Branch, errDiverged, ahead, behind)
+		return
+
+	case behind == 0:
+		state = "latest"
+
+	default:
+		logger.Debugf("Fast-forwarding %s to upstream/%s", repo.Branch, parentBranch)
+		if err := fastForwardBranch(repository, branchName, upstream.Hash()); err != nil {
+			logger.Debugf("Failed to fast-forward: %v", err)
+			status.appendRow(repo.Directory, repo.Branch, err, ahead, behind)
+			return
+		}
+
+		state = "synced"
+
+	}
+
+	if !push {
+		status.appendRow(repo.Directory, repo.Branch, state, ahead, behind)
+		return
+	}
+
+	auth, err := getRemoteAuthMethod(conf, repo, repository, git.DefaultRemoteName)
+	if err != nil {
+		logger.Debugf("Failed to retrieve authentication: %v", err)
+		status.appendRow(repo.Directory, repo.Branch, state, ahead, behind, err)
+		return
+	}
+
+	logger.Debug("Pushing to origin")
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("%[1]s:%[1]s", branchName))
+	switch err := repository.Push(&git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []gitconfig.RefSpec{refSpec},
+		Auth:       auth,
+	}); {
+
+	case errors.Is(err, git.NoErrAlreadyUpToDate):
+		status.appendRow(repo.Directory, repo.Branch, state, ahead, behind, "latest")
+
+	case err != nil:
+		logger.Debugf("Failed to push: %v", err)
+		status.appendRow(repo.Directory, repo.Branch, state, ahead, behind, err)
+
+	default:
+		status.appendRow(repo.Directory, repo.Branch, state, ahead, behind, "pushed")
+
+	}
+}
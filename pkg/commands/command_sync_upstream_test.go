@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	billyutil "github.com/go-git/go-billy/v5/util"
+	git "github.com/go-git/go-git/v5"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	server "github.com/go-git/go-git/v5/plumbing/transport/server"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+func TestSyncUpstreamOperation(t *testing.T) {
+	serveFileProtocol(t, server.DefaultLoader)
+
+	for _, tt := range []struct {
+		name     string
+		checkout string
+		local    bool
+		dirty    bool
+		want     string
+		wantSync bool
+	}{
+		{"test#1", "master", false, false, "local master synced 0 2", true},
+		{"test#2", "feature", false, true, "local master synced 0 2", true},
+		{"test#3", "master", true, false, "local master diverged 1 2", false},
+		{"test#4", "master", false, true, "local master " + git.ErrWorktreeNotClean.Error() + " 0 2", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			upstream, err := git.PlainInit(filepath.Join(dir, "upstream"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			upstreamTree, err := upstream.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_ = commitFiles(t, upstreamTree, "base", map[string]string{"a.txt": "a\n"})
+
+			// the in-process server cannot negotiate commits it does not know, hence the local commit is made in the parent
+			var side plumbing.Hash
+			if tt.local {
+				for _, branch := range []string{"side", "master"} {
+					if err := upstreamTree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: branch == "side"}); err != nil {
+						t.Fatal(err)
+					}
+
+					if branch == "side" {
+						side = commitFiles(t, upstreamTree, "local", map[string]string{"d.txt": "d\n"})
+					}
+				}
+			}
+
+			// the parent repository serves as "origin" as well, which is not used without --push
+			parentURL := filepath.Join(upstreamTree.Filesystem.Root(), git.GitDirName)
+			local, err := git.PlainClone(filepath.Join(dir, "local"), false, &git.CloneOptions{URL: parentURL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			localTree, err := local.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_ = commitFiles(t, upstreamTree, "upstream#1", map[string]string{"b.txt": "b\n"})
+			want := commitFiles(t, upstreamTree, "upstream#2", map[string]string{"c.txt": "c\n"})
+
+			if tt.checkout != "master" {
+				if err := localTree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(tt.checkout), Create: true}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.local {
+				if err := localTree.Reset(&git.ResetOptions{Mode: git.HardReset, Commit: side}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.dirty {
+				if err := billyutil.WriteFile(localTree.Filesystem, "a.txt", []byte("dirty\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			before, err := local.Reference(plumbing.NewBranchReferenceName("master"), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status := newOperationStatus()
+			syncUpstreamOperation(nil, newOperationContext(operationContextMap{
+				"conf":   &configfile.Configuration{AbsoluteDirectoryPath: dir},
+				"object": configfile.Repository{Directory: "local", URL: parentURL, ParentURL: parentURL, Branch: "master"},
+				"status": status,
+				"push":   false,
+			}))
+
+			if got := strings.Fields(status.Sprint()); !slices.Equal(got, strings.Fields(tt.want)) {
+				t.Errorf("syncUpstreamOperation() failed: got: %v, want: %v", got, tt.want)
+			}
+
+			branch, err := local.Reference(plumbing.NewBranchReferenceName("master"), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.wantSync {
+				want = before.Hash()
+			}
+
+			if branch.Hash() != want {
+				t.Errorf("syncUpstreamOperation() failed: got master: %s, want: %s", branch.Hash(), want)
+			}
+
+			// the work tree follows the checked out branch and keeps its changes otherwise
+			wantContent := map[bool]string{false: "a\n", true: "dirty\n"}[tt.dirty]
+			if got := readFile(t, localTree, "a.txt"); got != wantContent {
+				t.Errorf("syncUpstreamOperation() failed: got a.txt: %q, want: %q", got, wantContent)
+			}
+
+			if _, err := localTree.Filesystem.Stat("c.txt"); (err == nil) != (tt.wantSync && tt.checkout == "master") {
+				t.Errorf("syncUpstreamOperation() failed: got c.txt: %v", err)
+			}
+		})
+	}
+}
@@ -1,15 +1,57 @@
 package commands
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	color "github.com/fatih/color"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	cobra "github.com/spf13/cobra"
 )
 
-// updateCmd represents the update command
-var updateCmd = &cobra.Command{
-	Use:     "update",
-	Short:   "Update configuration and fetch repositories",
-	Example: "gh pr update",
-	Run: func(*cobra.Command, []string) {
-		initializeOrUpdateConfig(nil, true)
-	},
+// Supported output formats of the repository diff of update command.
+const (
+	updateFormatTable = "table"
+	updateFormatJSON  = "json"
+)
+
+// updateFlags represents the flags for update command
+var updateFlags struct {
+	dryRun       bool
+	formatOption string
+	strict       bool
 }
+
+// updateCmd represents the update command
+var updateCmd = func() *cobra.Command {
+	updateCmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update configuration and fetch repositories",
+		Long: "Update configuration and fetch repositories.\n\n" +
+			"Hosts of all configured accounts are queried in parallel and the outcome is summarized for each account.\n" +
+			"Accounts, which could not be queried, retain their previously configured profile and repositories.\n" +
+			"The update fails only if no account could be queried, or if any account failed in strict mode.\n" +
+			"Local clones of repositories renamed or transferred on the server are moved into their new directories.\n" +
+			"Added, removed and changed repositories are listed before the configuration is saved, " +
+			"the changes have to be confirmed in interactive terminals.",
+		Example: "gh gr update --dry-run --format json",
+		Run: func(*cobra.Command, []string) {
+			if !slices.Contains([]string{updateFormatTable, updateFormatJSON}, updateFlags.formatOption) {
+				util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, "Unsupported format: %q", updateFlags.formatOption))
+			}
+
+			validateProfileFlags()
+			initializeOrUpdateConfig(nil, true)
+		},
+	}
+
+	flags := updateCmd.Flags()
+	flags.BoolVar(&updateFlags.dryRun, "dry-run", false, "List added, removed and changed repositories without saving the configuration")
+	supportedFormats := strings.Join([]string{updateFormatTable, updateFormatJSON}, ", ")
+	flags.StringVarP(&updateFlags.formatOption, "format", "f", updateFormatTable, fmt.Sprintf("Change output format of the changes, supported formats: [%s]", supportedFormats))
+	addProfileFlags(updateCmd)
+	flags.BoolVar(&updateFlags.strict, "strict", false, "Fail if any host could not be queried (by default, only if all hosts failed, previously configured repositories of failing hosts are retained)")
+
+	return updateCmd
+}()
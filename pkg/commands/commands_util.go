@@ -5,21 +5,47 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	color "github.com/fatih/color"
+	billyutil "github.com/go-git/go-billy/v5/util"
 	git "github.com/go-git/go-git/v5"
 	gitconfig "github.com/go-git/go-git/v5/config"
 	plumbing "github.com/go-git/go-git/v5/plumbing"
+	filemode "github.com/go-git/go-git/v5/plumbing/filemode"
+	object "github.com/go-git/go-git/v5/plumbing/object"
+	transport "github.com/go-git/go-git/v5/plumbing/transport"
+	filesystem "github.com/go-git/go-git/v5/storage/filesystem"
 	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
 	extras "github.com/sarumaj/gh-gr/v2/pkg/extras"
 	restclient "github.com/sarumaj/gh-gr/v2/pkg/restclient"
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
 	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	supererrors "github.com/sarumaj/go-super/errors"
 	logrus "github.com/sirupsen/logrus"
 )
 
+// autoStashRef references the commit holding changes stashed by pull.
+const autoStashRef plumbing.ReferenceName = "refs/gr/autostash"
+
+// errConflict is returned if changes cannot be combined automatically.
+var errConflict = errors.New("conflict")
+
+// errDiverged is returned if a local branch and its remote counterpart have diverged.
+var errDiverged = errors.New("diverged")
+
+// fetchHeadFile is the name of the file inside of the git directory recording the references fetched last.
+const fetchHeadFile = "FETCH_HEAD"
+
 // addGitAliases adds git aliases to .gitconfig.
 func addGitAliases() error {
 	var ga []struct {
@@ -83,6 +109,284 @@ func changeProgressbarText[U interface {
 	}
 }
 
+// collectAncestors walks the commit history starting at given hashes and collects all visited commits.
+// Commits enlisted in seen are neither collected nor traversed.
+// Missing objects (e.g. beyond the boundary of a shallow clone) are skipped.
+func collectAncestors(repository *git.Repository, seen map[plumbing.Hash]bool, from ...plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	visited := make(map[plumbing.Hash]bool)
+	queue := append([]plumbing.Hash{}, from...)
+
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if hash.IsZero() || visited[hash] || seen[hash] {
+			continue
+		}
+
+		switch commit, err := repository.CommitObject(hash); {
+
+		case errors.Is(err, plumbing.ErrObjectNotFound):
+			continue
+
+		case err != nil:
+			return nil, err
+
+		default:
+			visited[hash] = true
+			queue = append(queue, commit.ParentHashes...)
+
+		}
+	}
+
+	return visited, nil
+}
+
+// countExclusiveCommits counts commits reachable from left but not from right (leftOnly) and vice versa (rightOnly).
+// Both histories are walked simultaneously, newest commits first, and the walk stops as soon as
+// all pending commits are reachable from both sides, i.e. at the merge bases.
+// Missing objects (e.g. beyond the boundary of a shallow clone) are skipped.
+func countExclusiveCommits(repository *git.Repository, left, right []plumbing.Hash) (leftOnly, rightOnly int, err error) {
+	const (
+		fromLeft uint8 = 1 << iota
+		fromRight
+		fromBoth = fromLeft | fromRight
+	)
+
+	type entry struct {
+		commit *object.Commit
+		flags  uint8
+	}
+
+	flags := make(map[plumbing.Hash]uint8)
+	var queue []entry
+	push := func(hash plumbing.Hash, flag uint8) error {
+		if hash.IsZero() || flags[hash]|flag == flags[hash] {
+			return nil
+		}
+
+		switch commit, err := repository.CommitObject(hash); {
+
+		case errors.Is(err, plumbing.ErrObjectNotFound):
+			return nil
+
+		case err != nil:
+			return err
+
+		default:
+			flags[hash] |= flag
+			queue = append(queue, entry{commit: commit, flags: flags[hash]})
+			return nil
+
+		}
+	}
+
+	for _, side := range []struct {
+		hashes []plumbing.Hash
+		flag   uint8
+	}{{left, fromLeft}, {right, fromRight}} {
+		for _, hash := range side.hashes {
+			if err := push(hash, side.flag); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	// commits reachable from both sides are walked further only to pass the flags on to already walked parents,
+	// which happens if timestamps of commits are equal or skewed
+	walked := make(map[plumbing.Hash]bool)
+	pending := func(e entry) bool {
+		return e.flags == flags[e.commit.Hash] && (e.flags != fromBoth || walked[e.commit.Hash])
+	}
+
+	for slices.ContainsFunc(queue, pending) {
+		newest := 0
+		for i, e := range queue {
+			if e.commit.Committer.When.After(queue[newest].commit.Committer.When) {
+				newest = i
+			}
+		}
+
+		current := queue[newest]
+		queue = slices.Delete(queue, newest, newest+1)
+
+		// an entry is outdated if the commit got reached from the other side in the meantime
+		if current.flags != flags[current.commit.Hash] {
+			continue
+		}
+
+		walked[current.commit.Hash] = true
+		for _, parent := range current.commit.ParentHashes {
+			if err := push(parent, current.flags); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	for _, flag := range flags {
+		switch flag {
+
+		case fromLeft:
+			leftOnly++
+
+		case fromRight:
+			rightOnly++
+
+		}
+	}
+
+	return leftOnly, rightOnly, nil
+}
+
+// countAheadBehind counts commits reachable from local but not from remote (ahead) and vice versa (behind).
+// Histories are walked down to the merge base only.
+func countAheadBehind(repository *git.Repository, local, remote plumbing.Hash) (ahead, behind int, err error) {
+	if local == remote {
+		return 0, 0, nil
+	}
+
+	return countExclusiveCommits(repository, []plumbing.Hash{local}, []plumbing.Hash{remote})
+}
+
+// ensureUpstreamRemote creates remote "upstream" pointing to the parent repository if it does not exist yet.
+func ensureUpstreamRemote(repository *git.Repository, parentURL string) error {
+	switch _, err := repository.Remote("upstream"); {
+
+	case parentURL != "" && errors.Is(err, git.ErrRemoteNotFound):
+		if _, err := repository.CreateRemote(&gitconfig.RemoteConfig{
+			Name: "upstream",
+			URLs: []string{parentURL},
+		}); err != nil {
+
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+// generalizeRemoteURLs removes credentials from HTTP(S) URLs of all remotes and submodules in given repository config.
+// The number of modified URLs is returned.
+func generalizeRemoteURLs(conf *configfile.Configuration, repoConf *gitconfig.Config) int {
+	var count int
+	generalize := func(targetURL *string) {
+		if parsed, err := url.Parse(*targetURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return
+		}
+
+		before := *targetURL
+		conf.GeneralizeURL(targetURL)
+		if *targetURL != before {
+			count++
+		}
+	}
+
+	for _, cfg := range repoConf.Remotes {
+		for i := range cfg.URLs {
+			generalize(&cfg.URLs[i])
+		}
+	}
+
+	for _, cfg := range repoConf.Submodules {
+		generalize(&cfg.URL)
+	}
+
+	return count
+}
+
+// getLastFetchTime retrieves the modification time of FETCH_HEAD.
+// Zero time is returned, if the repository has never been fetched.
+func getLastFetchTime(repository *git.Repository) (time.Time, error) {
+	storage, ok := repository.Storer.(*filesystem.Storage)
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	switch info, err := storage.Filesystem().Stat(fetchHeadFile); {
+
+	case errors.Is(err, os.ErrNotExist):
+		return time.Time{}, nil
+
+	case err != nil:
+		return time.Time{}, err
+
+	default:
+		return info.ModTime(), nil
+
+	}
+}
+
+// getRemoteAuthMethod retrieves authentication for the URL of given remote using the profile owning the repository.
+func getRemoteAuthMethod(conf *configfile.Configuration, repo configfile.Repository, repository *git.Repository, remoteName string) (transport.AuthMethod, error) {
+	remote, err := repository.Remote(remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		return conf.GetAuthMethod(repo, urls[0])
+	}
+
+	return nil, nil
+}
+
+// getRemoteDefaultBranch retrieves the name of the branch the HEAD of given remote points to.
+// An empty name is returned, if the remote does not advertise its HEAD.
+func getRemoteDefaultBranch(repository *git.Repository, remoteName string, auth transport.AuthMethod) (string, error) {
+	remote, err := repository.Remote(remoteName)
+	if err != nil {
+		return "", err
+	}
+
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return "", err
+	}
+
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return ref.Target().Short(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// getTagMode translates configured tag mode into the one of go-git.
+// An empty tag mode results in the default of go-git.
+func getTagMode(tagMode string) (git.TagMode, error) {
+	switch tagMode {
+
+	case "":
+		return git.InvalidTagMode, nil
+
+	case configfile.TagModeAll:
+		return git.AllTags, nil
+
+	case configfile.TagModeFollowing:
+		return git.TagFollowing, nil
+
+	case configfile.TagModeNone:
+		return git.NoTags, nil
+
+	default:
+		return git.InvalidTagMode, fmt.Errorf("unsupported tag mode: %q", tagMode)
+
+	}
+}
+
+// getUpstreamReferenceName retrieves the name of the remote-tracking reference given local branch is tracking.
+// If the branch has no upstream configured, the remote-tracking reference of the same name on "origin" is assumed
+// and configured is reported as false.
+func getUpstreamReferenceName(repoConf *gitconfig.Config, branch string) (ref plumbing.ReferenceName, configured bool) {
+	if cfg, ok := repoConf.Branches[branch]; ok && cfg.Remote != "" && cfg.Merge != "" {
+		return plumbing.NewRemoteReferenceName(cfg.Remote, cfg.Merge.Short()), true
+	}
+
+	return plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), false
+}
+
 // initializeOrUpdateConfig initializes or updates app configuration.
 func initializeOrUpdateConfig(conf *configfile.Configuration, update bool) {
 	var logger *logrus.Entry
@@ -116,6 +420,12 @@ func initializeOrUpdateConfig(conf *configfile.Configuration, update bool) {
 
 	}
 
+	if err := configfile.ValidateDirectoryTemplate(conf.DirectoryTemplate); err != nil {
+		util.PrintlnAndExit("%s", c.CheckColors(color.RedString, "Invalid directory template: %v", err))
+	}
+
+	// host and repository specific settings are carried over on update
+	previousProfiles, previous := conf.Profiles, conf.Repositories
 	if update {
 		conf.Profiles = nil
 		conf.Repositories = nil
@@ -125,44 +435,372 @@ func initializeOrUpdateConfig(conf *configfile.Configuration, update bool) {
 
 	}
 
-	tokens := configfile.GetTokens()
+	// network settings are applied upfront, since they are needed to reach the hosts
+	for host, proxy := range profileFlags.proxies {
+		conf.Hosts.Get(host).Proxy = proxy
+	}
+
+	for host, caBundle := range profileFlags.caBundles {
+		conf.Hosts.Get(host).CABundle = caBundle
+	}
+
+	for host, certFile := range profileFlags.clientCertFiles {
+		conf.Hosts.Get(host).ClientCertFile = certFile
+	}
+
+	for host, keyFile := range profileFlags.clientKeyFiles {
+		conf.Hosts.Get(host).ClientKeyFile = keyFile
+	}
+
+	supererrors.Except(conf.Hosts.InstallGitTransport())
+
+	tokens := conf.GetTokens()
 	logger.Debugf("Retrieved tokens: %d", len(tokens))
 
 	defer util.PreventInterrupt().Stop()
-	for host, token := range tokens {
-		client, err := restclient.NewRESTClient(conf, restclient.ClientOptions{
-			AuthToken:   token,
-			Log:         logger.WriterLevel(logrus.DebugLevel),
-			LogColorize: util.Console().ColorsEnabled(),
-			Host:        host,
-		}, globalNonPersistentFlags.retry)
-		supererrors.Except(err)
+	// hosts are queried in parallel, the results are processed in order,
+	// so that repositories shared by multiple accounts are owned deterministically
+	accounts := slices.Sorted(maps.Keys(tokens))
+	results := make([]accountResult, len(accounts))
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = queryAccount(conf, account, tokens[account], previousProfiles, logger)
+		}()
+	}
+	wg.Wait()
+
+	printer := util.NewTablePrinter().SetHeader("Host", "Account", "Repositories", "Status")
+	failed := applyAccountResults(conf, results, previousProfiles, previous, printer, logger)
+
+	// the summary would interfere with the diff in JSON format
+	if len(results) > 0 && !(update && updateFlags.formatOption == updateFormatJSON) {
+		printer.Align().Print()
+	}
+
+	strict := initFlags.strict
+	if update {
+		strict = updateFlags.strict
+	}
+
+	// the configuration is kept unchanged if no host could be queried or if any failure is not tolerated
+	if !failuresTolerated(failed, len(results), strict) {
+		util.PrintlnAndExit("Failed to query %d of %d accounts", failed, len(results))
+	}
+
+	// repositories sharing a directory cannot be cloned, the configuration is kept unchanged until the layout is adjusted
+	if collisions := conf.Repositories.Collisions(); len(collisions) > 0 {
+		for _, dir := range slices.Sorted(maps.Keys(collisions)) {
+			logger.Warnf("Directory %s is claimed by multiple repositories: %s", dir, strings.Join(collisions[dir], ", "))
+		}
+
+		util.PrintlnAndExit(
+			"Found %d directories claimed by multiple repositories, consider a directory template distinguishing them (e.g. %q)",
+			len(collisions), configfile.DirectoryPlaceholderHost+"/"+configfile.DirectoryPlaceholderOwner+"/"+configfile.DirectoryPlaceholderRepo,
+		)
+	}
+
+	if update && !confirmRepositoriesDiff(conf.Repositories.Diff(previous)) {
+		if updateFlags.formatOption != updateFormatJSON {
+			_ = supererrors.ExceptFn(supererrors.W(fmt.Fprintln(c.Stdout(), c.CheckColors(color.YellowString, "Configuration not saved."))))
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
-		defer cancel()
+		return
+	}
+
+	// local clones of renamed or transferred repositories are moved rather than cloned again
+	if renames := conf.Repositories.Renames(previous); len(renames) > 0 {
+		moveRenamedRepositories(conf, renames)
+	}
+
+	if err := addGitAliases(); err != nil {
+		logger.Debugf("failed to set up git alias commands: %v", err)
+	}
 
-		user, err := client.GetUser(ctx)
-		supererrors.Except(err)
+	conf.Profiles.Inherit(previousProfiles)
+	for i, profile := range conf.Profiles {
+		if provider, ok := profileFlags.providers[profile.Host]; ok {
+			conf.Profiles[i].Provider = provider
+		}
 
-		profile := configfile.NewProfile(user, host)
-		conf.Profiles.Append(profile)
-		logger.Debugf("Username: %s, name: %s, email: %s", profile.Username, profile.Fullname, profile.Email)
+		// account specific flags take precedence over host specific ones
+		for _, key := range []string{profile.Host, profile.Key()} {
+			if transportName, ok := profileFlags.transports[key]; ok {
+				conf.Profiles[i].Transport = transportName
+			}
 
-		repos, err := client.GetAllUserRepos(ctx, conf.Included, conf.Excluded)
-		supererrors.Except(err)
-		logger.Debugf("Retrieved %d user repositories", len(repos))
+			if keyFile, ok := profileFlags.sshKeyFiles[key]; ok {
+				conf.Profiles[i].SSHKeyFile = keyFile
+			}
+		}
+	}
+
+	conf.Repositories.Inherit(previous)
+	conf.Save()
+}
+
+// applyAccountResults adds the profiles and the repositories of queried accounts to the configuration.
+// Failing accounts retain their previous profiles and repositories.
+// It returns the number of failing accounts.
+func applyAccountResults(conf *configfile.Configuration, results []accountResult, previousProfiles configfile.Profiles, previous configfile.Repositories, printer *util.TablePrinter, logger *logrus.Entry) (failed int) {
+	// successful accounts are processed first, so that profiles retained for failing accounts do not shadow them
+	slices.SortStableFunc(results, func(a, b accountResult) int {
+		switch {
+		case a.err == nil && b.err != nil:
+			return -1
+
+		case a.err != nil && b.err == nil:
+			return 1
+
+		default:
+			return 0
+		}
+	})
 
+	for _, result := range results {
+		login, host := result.split()
+		if result.err != nil {
+			failed++
+			logger.Warnf("Failed to query %s: %v", result.account, result.err)
+			retained := conf.RetainAccount(result.account, previousProfiles, previous)
+			printer.AddRowField(host).AddRowField(login).AddRowField(fmt.Sprintf("%d (retained)", retained)).AddRowField(result.err.Error(), color.FgRed).EndRow()
+			continue
+		}
+
+		if conf.Profiles.Has(*result.profile) {
+			logger.Debugf("Account %s already configured", result.profile.Key())
+			printer.AddRowField(host).AddRowField(result.profile.Username).AddRowField("0").AddRowField("already configured", color.FgYellow).EndRow()
+			continue
+		}
+
+		conf.Profiles.Append(result.profile)
+		logger.Debugf("Username: %s, name: %s, email: %s", result.profile.Username, result.profile.Fullname, result.profile.Email)
+
+		repos := result.repos
 		conf.FilterRepositories(&repos)
 		logger.Debugf("Applied filters: %d repositories remaining", len(repos))
 
-		conf.AppendRepositories(user, repos...)
+		conf.AppendRepositories(result.profile, repos...)
+		printer.AddRowField(host).AddRowField(result.profile.Username).AddRowField(fmt.Sprint(len(repos))).AddRowField("ok", color.FgGreen).EndRow()
+	}
+
+	return failed
+}
+
+// failuresTolerated reports whether given number of failing accounts is tolerated (by default, unless all accounts failed).
+func failuresTolerated(failed, total int, strict bool) bool {
+	return failed == 0 || (failed < total && !strict)
+}
+
+// accountResult holds the outcome of querying the profile and the repositories of an account.
+type accountResult struct {
+	account string // <username>@<host> or <host>
+	profile *configfile.Profile
+	repos   []resources.Repository
+	err     error
+}
+
+// split splits the account into username and host (username is empty if not known yet).
+func (r accountResult) split() (login, host string) {
+	login, host, found := strings.Cut(r.account, "@")
+	if !found {
+		return "", r.account
+	}
+
+	return login, host
+}
+
+// queryAccount retrieves the profile and the repositories of given account.
+// The provider of the host is carried over from previous profiles, unless given explicitly.
+func queryAccount(conf *configfile.Configuration, account, token string, previousProfiles configfile.Profiles, logger *logrus.Entry) (result accountResult) {
+	result.account = account
+	login, host := result.split()
+
+	// installation tokens of GitHub Apps are not associated with a user
+	installation := strings.HasSuffix(login, "[bot]")
+
+	provider := configfile.ProviderGitHub
+	if previous, ok := previousProfiles.ForHost(host); ok {
+		provider = previous.GetProvider()
+	}
+
+	if name, ok := profileFlags.providers[host]; ok {
+		provider = name
+	}
 
-		if err := addGitAliases(); err != nil {
-			logger.Debugf("failed to set up git alias commands: %v", err)
+	client, err := restclient.NewProvider(conf, provider, restclient.ClientOptions{
+		AuthToken:   token,
+		Log:         logger.WriterLevel(logrus.DebugLevel),
+		LogColorize: util.Console().ColorsEnabled(),
+		Host:        host,
+	}, globalNonPersistentFlags.retry)
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	var user *resources.User
+	if installation {
+		user, err = client.GetUserByLogin(ctx, login)
+	} else {
+		user, err = client.GetUser(ctx)
+	}
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	result.profile = configfile.NewProfile(user, host)
+	if provider != configfile.ProviderGitHub {
+		result.profile.Provider = provider
+	}
+
+	if installation {
+		result.repos, err = client.GetInstallationRepos(ctx)
+		// access is granted by the permissions of the installation
+		for i := range result.repos {
+			result.repos[i].Permissions.Pull, result.repos[i].Permissions.Push = true, true
 		}
+
+	} else {
+		result.repos, err = client.GetAllUserRepos(ctx, conf.Included, conf.Excluded)
+
+	}
+	if err != nil {
+		result.err = err
+		return
 	}
 
-	conf.Save()
+	logger.Debugf("Retrieved %d repositories of %s", len(result.repos), account)
+	return
+}
+
+// confirmRepositoriesDiff prints the difference to the saved repositories and asks for confirmation in interactive terminals.
+// The changes are not confirmed in dry run mode.
+func confirmRepositoriesDiff(diff configfile.RepositoriesDiff) bool {
+	c := util.Console()
+	if updateFlags.formatOption == updateFormatJSON {
+		raw := supererrors.ExceptFn(supererrors.W(json.MarshalIndent(diff, "", "  ")))
+		_ = supererrors.ExceptFn(supererrors.W(fmt.Fprintln(c.Stdout(), string(raw))))
+	} else {
+		printRepositoriesDiff(diff)
+	}
+
+	return !updateFlags.dryRun && diff.Confirm()
+}
+
+// printRepositoriesDiff prints added, removed and changed repositories in tabular form.
+func printRepositoriesDiff(diff configfile.RepositoriesDiff) {
+	c := util.Console()
+	if diff.Empty() {
+		_ = supererrors.ExceptFn(supererrors.W(fmt.Fprintln(c.Stdout(), c.CheckColors(color.GreenString, "No repositories changed."))))
+		return
+	}
+
+	printer := util.NewTablePrinter().SetHeader("Repository", "Change", "Details")
+	for _, repo := range diff.Added {
+		printer.AddRowField(repo.Directory).AddRowField("added", color.FgGreen).AddRowField(repo.URL).EndRow()
+	}
+
+	for _, repo := range diff.Removed {
+		printer.AddRowField(repo.Directory).AddRowField("removed", color.FgRed).AddRowField(repo.URL).EndRow()
+	}
+
+	for _, repo := range diff.Changed {
+		var details []string
+		for _, attribute := range slices.Sorted(maps.Keys(repo.Changes)) {
+			change := repo.Changes[attribute]
+			details = append(details, fmt.Sprintf("%s: %q -> %q", attribute, change.From, change.To))
+		}
+
+		printer.AddRowField(repo.Directory).AddRowField("changed", color.FgYellow).AddRowField(strings.Join(details, ", ")).EndRow()
+	}
+
+	printer.Sort().Align().Print()
+}
+
+// moveRenamedRepositories moves local clones of renamed or transferred repositories into their new directories
+// and points their remotes to the new URLs. The plan is printed along with the outcome of every move.
+func moveRenamedRepositories(conf *configfile.Configuration, renames []configfile.RepositoryRename) {
+	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
+
+	status := newOperationStatus()
+	status.SetHeader("Repository", "Status", "Previous directory")
+	for _, rename := range renames {
+		switch moved, err := moveRepository(conf, rename); {
+
+		case err != nil:
+			status.appendRow(rename.To.Directory, err, rename.From.Directory)
+
+		case moved:
+			status.appendRow(rename.To.Directory, "moved", rename.From.Directory)
+
+		default:
+			status.appendRow(rename.To.Directory, "not cloned", rename.From.Directory)
+
+		}
+	}
+
+	status.Sort().Print()
+}
+
+// moveRepository moves the local clone of a renamed or transferred repository into its new directory
+// and rewrites the URLs of its remotes. Repositories, which have not been cloned yet, are not moved.
+func moveRepository(conf *configfile.Configuration, rename configfile.RepositoryRename) (bool, error) {
+	logger := loggerEntry.WithField("repository", rename.From.Directory)
+
+	if !util.PathExists(rename.From.Directory) {
+		logger.Debug("Local repository does not exist")
+		return false, nil
+	}
+
+	if util.PathExists(rename.To.Directory) {
+		return false, fmt.Errorf("%s already exists", rename.To.Directory)
+	}
+
+	logger.Debugf("Moving to %s", rename.To.Directory)
+	if err := os.MkdirAll(filepath.Dir(rename.To.Directory), os.ModePerm); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(rename.From.Directory, rename.To.Directory); err != nil {
+		return false, err
+	}
+
+	// parent directories left empty (e.g. of the previous owner) are removed
+	for parent := filepath.Dir(rename.From.Directory); parent != "." && parent != conf.BaseDirectory; parent = filepath.Dir(parent) {
+		if entries, err := os.ReadDir(parent); err != nil || len(entries) > 0 || os.Remove(parent) != nil {
+			break
+		}
+	}
+
+	repository, err := git.PlainOpen(rename.To.Directory)
+	if err != nil {
+		return true, err
+	}
+
+	repoConf, err := repository.Config()
+	if err != nil {
+		return true, err
+	}
+
+	for remoteName, remoteURL := range map[string]string{git.DefaultRemoteName: conf.GetRemoteURL(rename.To), "upstream": conf.GetParentURL(rename.To)} {
+		if remote, ok := repoConf.Remotes[remoteName]; ok && remoteURL != "" {
+			logger.Debugf("Rewriting %s to %s", remoteName, remoteURL)
+			remote.URLs = []string{remoteURL}
+		}
+	}
+
+	if err := repoConf.Validate(); err != nil {
+		return true, err
+	}
+
+	return true, repository.Storer.SetConfig(repoConf)
 }
 
 // openRepository opens repository at given path.
@@ -183,6 +821,174 @@ func openRepository(repo configfile.Repository, status *operationStatus) (*git.R
 	}
 }
 
+// listRemoteTrackingRefs lists remote-tracking references of given remote.
+func listRemoteTrackingRefs(repository *git.Repository, remoteName string) (map[plumbing.ReferenceName]plumbing.Hash, error) {
+	refs, err := repository.References()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := plumbing.NewRemoteReferenceName(remoteName, "").String()
+	result := make(map[plumbing.ReferenceName]plumbing.Hash)
+	if err := refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference && strings.HasPrefix(ref.Name().String(), prefix) {
+			result[ref.Name()] = ref.Hash()
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// listTreeEntries lists all non-directory entries of the tree of given commit keyed by their full path.
+func listTreeEntries(repository *git.Repository, commitHash plumbing.Hash) (map[string]object.TreeEntry, error) {
+	commit, err := repository.CommitObject(commitHash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	entries := make(map[string]object.TreeEntry)
+	for {
+		switch name, entry, err := walker.Next(); {
+
+		case errors.Is(err, io.EOF):
+			return entries, nil
+
+		case err != nil:
+			return nil, err
+
+		case entry.Mode != filemode.Dir:
+			entries[name] = object.TreeEntry{Name: name, Mode: entry.Mode, Hash: entry.Hash}
+
+		}
+	}
+}
+
+// mergeTreeEntries performs a three-way merge of tree entries on file level.
+// A file changed on one side only is taken from that side.
+// Files changed differently on both sides are not merged, but reported as conflicts.
+func mergeTreeEntries(base, ours, theirs map[string]object.TreeEntry) (merged map[string]object.TreeEntry, conflicts []string) {
+	paths := make(map[string]bool)
+	for _, entries := range []map[string]object.TreeEntry{base, ours, theirs} {
+		for path := range entries {
+			paths[path] = true
+		}
+	}
+
+	merged = make(map[string]object.TreeEntry)
+	for path := range paths {
+		b, inBase := base[path]
+		o, inOurs := ours[path]
+		t, inTheirs := theirs[path]
+
+		var entry object.TreeEntry
+		var exists bool
+
+		switch {
+
+		case inOurs == inTheirs && o == t, inBase == inOurs && b == o:
+			entry, exists = t, inTheirs
+
+		case inBase == inTheirs && b == t:
+			entry, exists = o, inOurs
+
+		default:
+			conflicts = append(conflicts, path)
+			continue
+
+		}
+
+		if exists {
+			merged[path] = entry
+		}
+	}
+
+	slices.Sort(conflicts)
+	return merged, conflicts
+}
+
+// newFetchOptions prepares options to fetch given remote according to the clone settings of given repository.
+// Authentication for the remote is supplied at runtime.
+// No depth is requested, so that shallow repositories are fetched incrementally and keep their shallow boundary
+// (fetching with a depth would truncate the history of the new commits and disconnect it from the local one).
+func newFetchOptions(conf *configfile.Configuration, repo configfile.Repository, repository *git.Repository, remoteName string) (*git.FetchOptions, error) {
+	tags, err := getTagMode(conf.GetTagMode(repo))
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := getRemoteAuthMethod(conf, repo, repository, remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &git.FetchOptions{
+		RemoteName: remoteName,
+		Auth:       auth,
+		Tags:       tags,
+	}, nil
+}
+
+// newSignature creates signature of the user configured in given repository config.
+func newSignature(repoConf *gitconfig.Config) *object.Signature {
+	signature := &object.Signature{Name: repoConf.User.Name, Email: repoConf.User.Email, When: time.Now()}
+	if signature.Name == "" {
+		signature.Name = "gr"
+	}
+
+	return signature
+}
+
+// parseGitHubApp parses specification of a GitHub App installation given as comma-separated key=value pairs
+// (keys: "host", "app-id", "installation-id" and "private-key").
+func parseGitHubApp(spec string) (configfile.GitHubApp, error) {
+	app := configfile.GitHubApp{Host: "github.com"}
+	for _, pair := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+
+		var err error
+		switch key {
+
+		case "host":
+			app.Host = value
+
+		case "app-id":
+			app.AppID, err = strconv.ParseInt(value, 10, 64)
+
+		case "installation-id":
+			app.InstallationID, err = strconv.ParseInt(value, 10, 64)
+
+		case "private-key":
+			app.PrivateKeyFile = value
+
+		default:
+			return app, fmt.Errorf("unsupported key: %q", key)
+
+		}
+
+		if err != nil {
+			return app, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if app.AppID == 0 || app.InstallationID == 0 || app.PrivateKeyFile == "" {
+		return app, fmt.Errorf("app-id, installation-id and private-key are required")
+	}
+
+	return app, nil
+}
+
 // resetRepository resets repository to given head.
 func resetRepository(workTree *git.Worktree, head *plumbing.Reference) error {
 	if err := workTree.Reset(&git.ResetOptions{
@@ -204,6 +1010,142 @@ func resetRepository(workTree *git.Worktree, head *plumbing.Reference) error {
 	return nil
 }
 
+// restoreStash reapplies changes stashed by stashChanges onto the current head.
+// Changes are written into the work tree (unstaged) and the stash is dropped afterwards.
+// Files changed both in the stash and in the current head are left untouched and the stash is kept.
+func restoreStash(repository *git.Repository, workTree *git.Worktree) error {
+	stash, err := repository.Reference(autoStashRef, true)
+	if err != nil {
+		return err
+	}
+
+	stashCommit, err := repository.CommitObject(stash.Hash())
+	if err != nil {
+		return err
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return err
+	}
+
+	base, err := listTreeEntries(repository, stashCommit.ParentHashes[0])
+	if err != nil {
+		return err
+	}
+
+	ours, err := listTreeEntries(repository, stashCommit.Hash)
+	if err != nil {
+		return err
+	}
+
+	theirs, err := listTreeEntries(repository, head.Hash())
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts := mergeTreeEntries(base, ours, theirs)
+
+	fs := workTree.Filesystem
+	for path, entry := range merged {
+		if current, ok := theirs[path]; (ok && current == entry) || entry.Mode == filemode.Submodule {
+			continue
+		}
+
+		blob, err := repository.BlobObject(entry.Hash)
+		if err != nil {
+			return err
+		}
+
+		reader, err := blob.Reader()
+		if err != nil {
+			return err
+		}
+
+		content, err := io.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			return err
+		}
+
+		_ = fs.Remove(path)
+		if entry.Mode == filemode.Symlink {
+			if err := fs.Symlink(string(content), path); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		perm := os.FileMode(0o644)
+		if entry.Mode == filemode.Executable {
+			perm = 0o755
+		}
+
+		if err := billyutil.WriteFile(fs, path, content, perm); err != nil {
+			return err
+		}
+	}
+
+	for path := range theirs {
+		if _, ok := merged[path]; !ok && !slices.Contains(conflicts, path) {
+			if err := fs.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", errConflict, strings.Join(conflicts, ", "))
+	}
+
+	return repository.Storer.RemoveReference(autoStashRef)
+}
+
+// stashChanges records all changes of the work tree (including untracked files) in a commit
+// referenced by autoStashRef and resets the work tree to the head afterwards.
+// An existing stash, which has not been restored yet, is never overwritten.
+func stashChanges(repository *git.Repository, workTree *git.Worktree) error {
+	switch _, err := repository.Reference(autoStashRef, false); {
+
+	case err == nil:
+		return fmt.Errorf("%w: unrestored stash at %s", errConflict, autoStashRef)
+
+	case !errors.Is(err, plumbing.ErrReferenceNotFound):
+		return err
+
+	}
+
+	head, err := repository.Head()
+	if err != nil {
+		return err
+	}
+
+	repoConf, err := repository.Config()
+	if err != nil {
+		return err
+	}
+
+	if err := workTree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return err
+	}
+
+	stash, err := workTree.Commit("gr: autostash on "+head.Name().Short(), &git.CommitOptions{
+		Author:  newSignature(repoConf),
+		Parents: []plumbing.Hash{head.Hash()},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := repository.Storer.SetReference(plumbing.NewHashReference(autoStashRef, stash)); err != nil {
+		return err
+	}
+
+	// moves the checked out branch back to where it was before committing the stash
+	return resetRepository(workTree, head)
+}
+
 // updateConfigFlags updates global configuration flags.
 func updateConfigFlags() {
 	var conf *configfile.Configuration
@@ -217,49 +1159,148 @@ func updateConfigFlags() {
 }
 
 // updateRepoConfig updates repository config.
-// If host is specified, it will update user name and email.
-// It will update remote "origin" and submodules' urls to use current personal access token.
-func updateRepoConfig(conf *configfile.Configuration, host string, repository *git.Repository) error {
+// If profile is specified, it will update user name and email.
+// It will remove credentials from the URLs of remotes and submodules (authentication is supplied at runtime).
+func updateRepoConfig(conf *configfile.Configuration, profile *configfile.Profile, repository *git.Repository) error {
 	repoConf, err := repository.Config()
 	if err != nil {
 		return err
 	}
 
-	// set user if host is specified
-	if host != "" {
-		profilesMap := conf.Profiles.ToMap()
-		profile, ok := profilesMap[host]
-		if !ok {
-			return fmt.Errorf("no profile for host: %q", host)
-		}
-
-		// set user
+	// set user if profile is specified
+	if profile != nil {
 		repoConf.User.Name = profile.Fullname
 		repoConf.User.Email = profile.Email
 	}
 
-	// update remote "origin" urls to use current authentication context
-	if cfg, ok := repoConf.Remotes["origin"]; ok {
-		for i := range cfg.URLs {
-			conf.AuthenticateURL(&cfg.URLs[i])
-		}
+	_ = generalizeRemoteURLs(conf, repoConf)
 
-		repoConf.Remotes["origin"] = cfg
+	if err := repoConf.Validate(); err != nil {
+		return err
 	}
 
-	// update submodules' urls to use current authentication context
-	for name, cfg := range repoConf.Submodules {
-		conf.AuthenticateURL(&cfg.URL)
-		repoConf.Submodules[name] = cfg
+	if err := repository.Storer.SetConfig(repoConf); err != nil {
+		return err
 	}
 
-	if err := repoConf.Validate(); err != nil {
+	return nil
+}
+
+// writeCommit stores given commit object and returns its hash.
+func writeCommit(repository *git.Repository, commit *object.Commit) (plumbing.Hash, error) {
+	obj := repository.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return repository.Storer.SetEncodedObject(obj)
+}
+
+// writeFetchHead records the remote-tracking references of given remote in FETCH_HEAD, like native git does.
+// go-git does not maintain FETCH_HEAD, but it is used to determine the time of the last fetch.
+func writeFetchHead(conf *configfile.Configuration, repository *git.Repository, remoteName string) error {
+	storage, ok := repository.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil
+	}
+
+	remote, err := repository.Remote(remoteName)
+	if err != nil {
 		return err
 	}
 
-	if err := repository.Storer.SetConfig(repoConf); err != nil {
+	var remoteURL string
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		remoteURL = urls[0]
+		conf.GeneralizeURL(&remoteURL)
+	}
+
+	refs, err := listRemoteTrackingRefs(repository, remoteName)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	var merge string
+	if head, err := repository.Head(); err == nil && head.Name().IsBranch() {
+		merge = head.Name().Short()
+	}
+
+	// the branch to be merged comes first, the other ones are marked as not-for-merge
+	var head, lines []string
+	for name, hash := range refs {
+		branch := strings.TrimPrefix(name.String(), plumbing.NewRemoteReferenceName(remoteName, "").String())
+		switch branch {
+
+		case "HEAD":
+
+		case merge:
+			head = append(head, fmt.Sprintf("%s\t\tbranch '%s' of %s\n", hash, branch, remoteURL))
+
+		default:
+			lines = append(lines, fmt.Sprintf("%s\tnot-for-merge\tbranch '%s' of %s\n", hash, branch, remoteURL))
+
+		}
+	}
+
+	slices.Sort(lines)
+	lines = append(head, lines...)
+
+	f, err := storage.Filesystem().Create(fetchHeadFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write([]byte(strings.Join(lines, ""))); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeTree stores tree objects for given entries keyed by their full path and returns the hash of the root tree.
+func writeTree(repository *git.Repository, entries map[string]object.TreeEntry) (plumbing.Hash, error) {
+	tree := &object.Tree{}
+	subtrees := make(map[string]map[string]object.TreeEntry)
+	for path, entry := range entries {
+		if dir, rest, found := strings.Cut(path, "/"); found {
+			if subtrees[dir] == nil {
+				subtrees[dir] = make(map[string]object.TreeEntry)
+			}
+
+			subtrees[dir][rest] = entry
+			continue
+		}
+
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: path, Mode: entry.Mode, Hash: entry.Hash})
+	}
+
+	for dir, subEntries := range subtrees {
+		hash, err := writeTree(repository, subEntries)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash})
+	}
+
+	// git sorts tree entries as if directory names had a trailing slash
+	sortKey := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+
+		return entry.Name
+	}
+
+	slices.SortFunc(tree.Entries, func(a, b object.TreeEntry) int {
+		return strings.Compare(sortKey(a), sortKey(b))
+	})
+
+	obj := repository.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return repository.Storer.SetEncodedObject(obj)
 }
@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	memfs "github.com/go-git/go-billy/v5/memfs"
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	plumbing "github.com/go-git/go-git/v5/plumbing"
+	filemode "github.com/go-git/go-git/v5/plumbing/filemode"
+	object "github.com/go-git/go-git/v5/plumbing/object"
+	memory "github.com/go-git/go-git/v5/storage/memory"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
+func TestCountAheadBehind(t *testing.T) {
+	repository, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workTree, err := repository.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shared history: c1 <- c2 <- c3, local: c3 <- l1 <- l2, remote: c3 <- r1, merge of l2 and r1
+	var shared []plumbing.Hash
+	for i := range 3 {
+		shared = append(shared, commitFiles(t, workTree, fmt.Sprintf("c%d", i+1), map[string]string{"a.txt": fmt.Sprint(i)}))
+	}
+
+	l1 := commitFiles(t, workTree, "l1", map[string]string{"l.txt": "1"})
+	l2 := commitFiles(t, workTree, "l2", map[string]string{"l.txt": "2"})
+	if err := workTree.Reset(&git.ResetOptions{Mode: git.HardReset, Commit: shared[2]}); err != nil {
+		t.Fatal(err)
+	}
+
+	r1 := commitFiles(t, workTree, "r1", map[string]string{"r.txt": "1"})
+	merge, err := workTree.Commit("merge", &git.CommitOptions{
+		Author:  &object.Signature{Name: "tester", Email: "tester@example.com"},
+		Parents: []plumbing.Hash{l2, r1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		local, remote plumbing.Hash
+	}
+
+	for _, tt := range []struct {
+		name       string
+		args       args
+		wantAhead  int
+		wantBehind int
+	}{
+		{"test#1", args{l2, l2}, 0, 0},
+		{"test#2", args{l2, shared[2]}, 2, 0},
+		{"test#3", args{shared[0], r1}, 0, 3},
+		{"test#4", args{l2, r1}, 2, 1},
+		{"test#5", args{l1, r1}, 1, 1},
+		{"test#6", args{merge, r1}, 3, 0},
+		{"test#7", args{l1, merge}, 0, 3},
+		{"test#8", args{l2, plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")}, 5, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ahead, behind, err := countAheadBehind(repository, tt.args.local, tt.args.remote)
+			if err != nil {
+				t.Fatalf("countAheadBehind() failed: %v", err)
+			}
+
+			if ahead != tt.wantAhead || behind != tt.wantBehind {
+				t.Errorf("countAheadBehind() failed: got: %d, %d, want: %d, %d", ahead, behind, tt.wantAhead, tt.wantBehind)
+			}
+		})
+	}
+}
+
+func TestMergeTreeEntries(t *testing.T) {
+	entry := func(name, content string) object.TreeEntry {
+		return object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: plumbing.ComputeHash(plumbing.BlobObject, []byte(content))}
+	}
+
+	entries := func(pairs ...string) map[string]object.TreeEntry {
+		m := make(map[string]object.TreeEntry)
+		for i := 0; i < len(pairs); i += 2 {
+			m[pairs[i]] = entry(pairs[i], pairs[i+1])
+		}
+
+		return m
+	}
+
+	type args struct {
+		base, ours, theirs map[string]object.TreeEntry
+	}
+
+	for _, tt := range []struct {
+		name          string
+		args          args
+		want          map[string]object.TreeEntry
+		wantConflicts []string
+	}{
+		{"test#1", args{entries("a", "1"), entries("a", "1"), entries("a", "1")}, entries("a", "1"), nil},
+		{"test#2", args{entries("a", "1", "b", "1"), entries("a", "2", "b", "1"), entries("a", "1", "b", "2")}, entries("a", "2", "b", "2"), nil},
+		{"test#3", args{entries("a", "1"), entries("a", "1", "b", "1"), entries("a", "1", "c", "1")}, entries("a", "1", "b", "1", "c", "1"), nil},
+		{"test#4", args{entries("a", "1", "b", "1"), entries("a", "1"), entries("a", "1", "b", "1")}, entries("a", "1"), nil},
+		{"test#5", args{entries("a", "1"), entries("a", "2"), entries("a", "2")}, entries("a", "2"), nil},
+		{"test#6", args{entries(), entries("a", "1"), entries("a", "2")}, entries(), []string{"a"}},
+		{"test#7", args{entries("a", "1"), entries("a", "2"), entries()}, entries(), []string{"a"}},
+		{"test#8", args{entries("a", "1"), entries(), entries("a", "2")}, entries(), []string{"a"}},
+		{"test#9", args{entries("a", "1", "b", "1"), entries("a", "2", "b", "2"), entries("a", "3", "b", "1")}, entries("b", "2"), []string{"a"}},
+		{"test#10", args{entries("a", "1", "b", "1"), entries("a", "2", "b", "2"), entries("a", "3", "b", "3")}, entries(), []string{"a", "b"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotConflicts := mergeTreeEntries(tt.args.base, tt.args.ours, tt.args.theirs)
+			if !slices.Equal(gotConflicts, tt.wantConflicts) {
+				t.Errorf("mergeTreeEntries() failed: got conflicts: %v, want: %v", gotConflicts, tt.wantConflicts)
+			}
+
+			for path, want := range tt.want {
+				if got[path] != want {
+					t.Errorf("mergeTreeEntries() failed: got %s: %v, want: %v", path, got[path], want)
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("mergeTreeEntries() failed: got: %s, want: %s", fmt.Sprint(got), fmt.Sprint(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseGitHubApp(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    string
+		want    configfile.GitHubApp
+		wantErr bool
+	}{
+		{"test#1", "app-id=1,installation-id=2,private-key=key.pem", configfile.GitHubApp{Host: "github.com", AppID: 1, InstallationID: 2, PrivateKeyFile: "key.pem"}, false},
+		{"test#2", "host=example.com, app-id=1, installation-id=2, private-key=~/key.pem", configfile.GitHubApp{Host: "example.com", AppID: 1, InstallationID: 2, PrivateKeyFile: "~/key.pem"}, false},
+		{"test#3", "app-id=1,installation-id=2", configfile.GitHubApp{}, true},
+		{"test#4", "app-id=one,installation-id=2,private-key=key.pem", configfile.GitHubApp{}, true},
+		{"test#5", "app-id=1,installation-id=2,private-key=key.pem,client-id=3", configfile.GitHubApp{}, true},
+		{"test#6", "", configfile.GitHubApp{}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitHubApp(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGitHubApp(%q) failed: got error: %v, want error: %t", tt.args, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseGitHubApp(%q) failed: got: %+v, want: %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveRepository(t *testing.T) {
+	base := t.TempDir()
+	conf := &configfile.Configuration{BaseDirectory: base}
+
+	from := configfile.Repository{
+		Directory: filepath.Join(base, "octocat", "hello-world"),
+		URL:       "https://example.com/octocat/hello-world.git",
+		ParentURL: "https://example.com/upstream/hello-world.git",
+	}
+
+	to := configfile.Repository{
+		Directory: filepath.Join(base, "hubot", "hello-universe"),
+		URL:       "https://example.com/hubot/hello-universe.git",
+		ParentURL: "https://example.com/upstream/hello-universe.git",
+	}
+
+	// nothing to move, if the repository has not been cloned yet
+	if moved, err := moveRepository(conf, configfile.RepositoryRename{From: from, To: to}); moved || err != nil {
+		t.Fatalf("moveRepository() failed: got: %t, %v, want: false, <nil>", moved, err)
+	}
+
+	repository, err := git.PlainInit(from.Directory, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, url := range map[string]string{git.DefaultRemoteName: from.URL, "upstream": from.ParentURL} {
+		if _, err := repository.CreateRemote(&gitconfig.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the target directory must not exist
+	if err := os.MkdirAll(to.Directory, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if moved, err := moveRepository(conf, configfile.RepositoryRename{From: from, To: to}); moved || err == nil {
+		t.Fatalf("moveRepository() failed: got: %t, %v, want: false, error", moved, err)
+	}
+
+	if err := os.Remove(to.Directory); err != nil {
+		t.Fatal(err)
+	}
+
+	if moved, err := moveRepository(conf, configfile.RepositoryRename{From: from, To: to}); !moved || err != nil {
+		t.Fatalf("moveRepository() failed: got: %t, %v, want: true, <nil>", moved, err)
+	}
+
+	// empty parent directories are removed, the base directory is kept
+	for _, dir := range []string{from.Directory, filepath.Dir(from.Directory)} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("moveRepository() failed: %s not removed: %v", dir, err)
+		}
+	}
+
+	if _, err := os.Stat(base); err != nil {
+		t.Errorf("moveRepository() failed: base directory removed: %v", err)
+	}
+
+	repository, err = git.PlainOpen(to.Directory)
+	if err != nil {
+		t.Fatalf("moveRepository() failed: repository not moved: %v", err)
+	}
+
+	repoConf, err := repository.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{git.DefaultRemoteName: to.URL, "upstream": to.ParentURL} {
+		if got := repoConf.Remotes[name].URLs; !slices.Equal(got, []string{want}) {
+			t.Errorf("moveRepository() failed: got remote %s: %v, want: [%s]", name, got, want)
+		}
+	}
+}
+
+func TestApplyAccountResults(t *testing.T) {
+	previousProfiles := configfile.Profiles{
+		{Username: "octocat", Host: "github.com"},
+		{Username: "hubot", Host: "example.com"},
+	}
+	previous := configfile.Repositories{
+		{Directory: "a", Profile: "octocat@github.com", URL: "https://github.com/octocat/a.git"},
+		{Directory: "b", Profile: "hubot@example.com", URL: "https://example.com/hubot/b.git"},
+	}
+
+	octocat := accountResult{
+		account: "octocat@github.com",
+		profile: &configfile.Profile{Username: "octocat", Host: "github.com"},
+		repos: []resources.Repository{{
+			FullName:    "octocat/c",
+			CloneURL:    "https://github.com/octocat/c.git",
+			Permissions: resources.Permissions{Pull: true, Push: true},
+		}},
+	}
+	failure := fmt.Errorf("unreachable")
+
+	for _, tt := range []struct {
+		name      string
+		args      []accountResult
+		want      int
+		wantRepos []string
+	}{
+		{"test#1", []accountResult{{account: "example.com", err: failure}, octocat}, 1,
+			[]string{"https://example.com/hubot/b.git", "https://github.com/octocat/c.git"}},
+		{"test#2", []accountResult{{account: "example.com", err: failure}, {account: "octocat@github.com", err: failure}}, 2,
+			[]string{"https://github.com/octocat/a.git", "https://example.com/hubot/b.git"}},
+		{"test#3", []accountResult{octocat}, 0,
+			[]string{"https://github.com/octocat/c.git"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &configfile.Configuration{}
+			printer := util.NewTablePrinter().SetHeader("Host", "Account", "Repositories", "Status")
+			got := applyAccountResults(conf, slices.Clone(tt.args), previousProfiles, previous, printer, loggerEntry)
+			if got != tt.want {
+				t.Errorf("applyAccountResults() failed: got: %d, want: %d", got, tt.want)
+			}
+
+			var repos []string
+			for _, repo := range conf.Repositories {
+				repos = append(repos, repo.URL)
+			}
+
+			if !slices.Equal(repos, tt.wantRepos) {
+				t.Errorf("applyAccountResults() failed: got repositories: %v, want: %v", repos, tt.wantRepos)
+			}
+
+			if len(conf.Profiles) != len(tt.args) {
+				t.Errorf("applyAccountResults() failed: got profiles: %v, want: %d", conf.Profiles, len(tt.args))
+			}
+		})
+	}
+}
+
+func TestFailuresTolerated(t *testing.T) {
+	type args struct {
+		failed, total int
+		strict        bool
+	}
+
+	for _, tt := range []struct {
+		name string
+		args args
+		want bool
+	}{
+		{"test#1", args{0, 2, false}, true},
+		{"test#2", args{0, 2, true}, true},
+		{"test#3", args{1, 2, false}, true},
+		{"test#4", args{1, 2, true}, false},
+		{"test#5", args{2, 2, false}, false},
+		{"test#6", args{0, 0, false}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failuresTolerated(tt.args.failed, tt.args.total, tt.args.strict); got != tt.want {
+				t.Errorf("failuresTolerated(%d, %d, %t) failed: got: %t, want: %t", tt.args.failed, tt.args.total, tt.args.strict, got, tt.want)
+			}
+		})
+	}
+}
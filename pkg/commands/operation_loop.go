@@ -30,7 +30,18 @@ func operationLoop[U interface {
 
 	batch := p.Batch()
 
-	logger.Debugf("Dispatching %d workers", len(conf.Repositories))
+	repositories := conf.Repositories
+	if filters, ok := args["filters"].([]string); ok {
+		repositories = repositories.Match(filters)
+	}
+
+	total := len(source)
+	var target U
+	if _, ok := any(target).(configfile.Repository); ok {
+		total = len(repositories)
+	}
+
+	logger.Debugf("Dispatching %d workers", total)
 
 	finished := make(chan bool)
 	status := newOperationStatus()
@@ -65,14 +76,13 @@ func operationLoop[U interface {
 		}
 	}
 
-	changeProgressbarText(bar, conf, verbInfinitive+"ing", configfile.Repository{})
+	changeProgressbarText(bar, conf, strings.TrimSuffix(verbInfinitive, "e")+"ing", configfile.Repository{})
 	defer util.PreventInterrupt().Stop()
 
 	go func(finished chan<- bool) {
-		var target U
 		switch any(target).(type) {
 		case configfile.Repository:
-			for _, object := range conf.Repositories {
+			for _, object := range repositories {
 				batch.Queue(worker(any(object).(U)))
 			}
 
@@ -101,7 +111,7 @@ func operationLoop[U interface {
 		}
 	}(finished)
 
-	_ = bar.ChangeMax(len(conf.Repositories))
+	_ = bar.ChangeMax(total)
 	for result := range batch.Results() {
 		value, err := result.Value(), result.Error()
 		if err != nil {
@@ -1,14 +1,20 @@
 /*
 Package commands provides the command line interface for the application.
 Available commands are:
+  - cache
   - cleanup
+  - exec
   - export
+  - fetch
   - init
   - import
   - pull
   - push
+  - rate-limit
+  - remote
   - remove
   - status
+  - sync-upstream
   - update
   - version
   - view
@@ -5,13 +5,15 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"net/url"
+	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	survey "github.com/AlecAivazis/survey/v2"
@@ -19,6 +21,9 @@ import (
 	config "github.com/cli/go-gh/v2/pkg/config"
 	prompter "github.com/cli/go-gh/v2/pkg/prompter"
 	color "github.com/fatih/color"
+	transport "github.com/go-git/go-git/v5/plumbing/transport"
+	http "github.com/go-git/go-git/v5/plumbing/transport/http"
+	ssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
 	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
 	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 	supererrors "github.com/sarumaj/go-super/errors"
@@ -29,6 +34,14 @@ import (
 // Attribute name to store the configuration inside of the GitHub CLI config.
 const configKey = "gr.conf"
 
+// Attribute names of the GitHub CLI config used to look up accounts and their tokens.
+const (
+	ghHostsKey      = "hosts"
+	ghOAuthTokenKey = "oauth_token"
+	ghUserKey       = "user"
+	ghUsersKey      = "users"
+)
+
 // Message, when authentication fails.
 const AuthenticationFailed = "Authentication for %q failed. Make sure to configure GitHub CLI for %q."
 
@@ -50,6 +63,42 @@ const DefaultExportDestination = "stdout"
 // Default source for import.
 const DefaultImportSource = "stdin"
 
+// Fetch policy to fetch into remote-tracking references and fast-forward local branches where possible.
+const FetchPolicyTracking = "tracking"
+
+// Fetch policy to mirror all remote references (local references get overwritten).
+const FetchPolicyMirror = "mirror"
+
+// Provider of repositories hosted on GitHub (default).
+const ProviderGitHub = "github"
+
+// Provider of repositories hosted on Gitea or Forgejo.
+const ProviderGitea = "gitea"
+
+// Pull strategy to fast-forward only (diverged branches are reported).
+const PullStrategyFastForwardOnly = "ff-only"
+
+// Pull strategy to create a merge commit for diverged branches.
+const PullStrategyMerge = "merge"
+
+// Pull strategy to rebase local commits of diverged branches onto the remote ones.
+const PullStrategyRebase = "rebase"
+
+// Tag mode to fetch all tags.
+const TagModeAll = "all"
+
+// Tag mode to fetch tags pointing into the fetched history only.
+const TagModeFollowing = "following"
+
+// Tag mode to fetch no tags.
+const TagModeNone = "none"
+
+// Transport to clone over HTTPS using personal access tokens.
+const TransportHTTPS = "https"
+
+// Transport to clone over SSH using ssh-agent or a key file.
+const TransportSSH = "ssh"
+
 // Regular expression used to split URL into components.
 var urlRegex = regexp.MustCompile(`(?P<Schema>[^:]+://)(?P<Creds>[^@]+@)?(?P<Hostpath>.+)`)
 
@@ -82,40 +131,86 @@ type Configuration struct {
 	BaseDirectory         string        `json:"baseDirectory" yaml:"baseDirectory"`
 	AbsoluteDirectoryPath string        `json:"directoryPath" yaml:"directoryPath"`
 	Profiles              Profiles      `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	Hosts                 Hosts         `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	AutoStash             bool          `json:"autoStash,omitempty" yaml:"autoStash,omitempty"`
+	CloneDepth            int           `json:"cloneDepth,omitempty" yaml:"cloneDepth,omitempty"`
 	Concurrency           uint          `json:"concurrency" yaml:"concurrency"`
+	DirectoryTemplate     string        `json:"directoryTemplate,omitempty" yaml:"directoryTemplate,omitempty"`
+	FetchPolicy           string        `json:"fetchPolicy,omitempty" yaml:"fetchPolicy,omitempty"`
+	OfflineStatus         bool          `json:"offlineStatus,omitempty" yaml:"offlineStatus,omitempty"`
+	PullStrategy          string        `json:"pullStrategy,omitempty" yaml:"pullStrategy,omitempty"`
+	SingleBranch          bool          `json:"singleBranch,omitempty" yaml:"singleBranch,omitempty"`
+	SSHKeyFile            string        `json:"sshKeyFile,omitempty" yaml:"sshKeyFile,omitempty"`
+	SSHKnownHosts         string        `json:"sshKnownHosts,omitempty" yaml:"sshKnownHosts,omitempty"`
 	SubDirectories        bool          `json:"subDirectories" yaml:"subDirectories"`
 	SizeLimit             uint64        `json:"sizeLimit" yaml:"sizeLimit"`
+	Tags                  string        `json:"tags,omitempty" yaml:"tags,omitempty"`
 	Timeout               time.Duration `json:"timeout" yaml:"timeout"`
+	TokenSources          TokenSources  `json:"tokenSources,omitempty" yaml:"tokenSources,omitempty"`
+	Transport             string        `json:"transport,omitempty" yaml:"transport,omitempty"`
 	Excluded              []string      `json:"excluded,omitempty" yaml:"excluded,omitempty"`
 	Included              []string      `json:"included,omitempty" yaml:"included,omitempty"`
 	Total                 int64         `json:"total,omitempty" yaml:"total,omitempty"`
 	Repositories          Repositories  `json:"repositories,omitempty" yaml:"repositories,omitempty"`
-}
 
-// AppendRepositories appends multiple repositories to the configuration and sorts them alphabetically by Directory.
-func (conf *Configuration) AppendRepositories(user *resources.User, repos ...resources.Repository) {
-	for _, repo := range repos {
-		dir := repo.FullName
-		if !conf.SubDirectories {
-			dir = strings.ReplaceAll(dir, "/", "_")
-			dir = strings.Replace(dir, user.Login+"_", "", 1)
-		}
+	// tokens of the configured token sources, resolved once (see GetTokens)
+	tokens map[string]string
+}
 
-		dir = filepath.Join(conf.BaseDirectory, filepath.FromSlash(dir))
-		util.PathSanitize(&dir)
+// Guards the resolution of tokens of all configurations.
+var tokensMu sync.Mutex
 
+// AppendRepositories appends multiple repositories owned by given profile to the configuration and sorts them alphabetically by Directory.
+func (conf *Configuration) AppendRepositories(profile *Profile, repos ...resources.Repository) {
+	for _, repo := range repos {
+		dir := conf.GetDirectory(profile, repo)
 		loggerEntry.Debugf("Appending %s", dir)
 
 		conf.Repositories.Append(Repository{
-			Branch:    repo.DefaultBranch,
-			Directory: dir,
-			ParentURL: repo.Parent.CloneURL,
-			Public:    !repo.Private,
-			Size:      util.IntToSizeBytes(repo.Size, 1024, 3),
-			URL:       repo.CloneURL,
+			Branch:       repo.DefaultBranch,
+			Directory:    dir,
+			ID:           repo.ID,
+			ParentURL:    repo.Parent.CloneURL,
+			ParentSSHURL: repo.Parent.SSHURL,
+			Profile:      profile.Key(),
+			Public:       !repo.Private,
+			Size:         util.IntToSizeBytes(repo.Size, 1024, 3),
+			SSHURL:       repo.SSHURL,
+			URL:          repo.CloneURL,
 		})
 	}
 
+	conf.sortRepositories()
+}
+
+// RetainAccount carries over the profiles of given account (<username>@<host> or <host>) and the repositories they own
+// from a previous configuration, e.g. if the host of the account could not be queried.
+// Accounts not associated with a user yet (<host>) retain all profiles of the host.
+// It returns the number of repositories retained.
+func (conf *Configuration) RetainAccount(account string, profiles Profiles, repos Repositories) int {
+	previous := Configuration{Profiles: profiles}
+	owners := make(map[string]bool)
+	for _, profile := range profiles {
+		if profile.Key() == account || profile.Host == account {
+			conf.Profiles.Append(&profile)
+			owners[profile.Key()] = true
+		}
+	}
+
+	var retained int
+	for _, repo := range repos {
+		if owner, ok := previous.GetProfile(repo); ok && owners[owner.Key()] && !conf.Repositories.Has(repo) {
+			conf.Repositories = append(conf.Repositories, repo)
+			retained++
+		}
+	}
+
+	conf.sortRepositories()
+	return retained
+}
+
+// sortRepositories sorts repositories alphabetically by Directory and updates the total.
+func (conf *Configuration) sortRepositories() {
 	slices.SortFunc(conf.Repositories, func(a, b Repository) int {
 		switch {
 		case a.Directory > b.Directory:
@@ -133,40 +228,6 @@ func (conf *Configuration) AppendRepositories(user *resources.User, repos ...res
 	loggerEntry.Debugf("Configured %d repositories", conf.Total)
 }
 
-// AuthenticateURL encodes username and token into URL.
-// In the case, no matching token can be found for given URL, emit message and exit.
-func (conf Configuration) AuthenticateURL(targetURL *string) {
-	loggerEntry.Debugf("Authenticating URL: %v", targetURL)
-	if targetURL == nil || *targetURL == "" || !urlRegex.MatchString(*targetURL) {
-		loggerEntry.Debugf("Got empty or invalid URL: %#v", targetURL)
-		return
-	}
-
-	hostname := util.GetHostnameFromPath(*targetURL)
-	profiles := conf.Profiles.ToMap()
-	tokens := GetTokens()
-
-	for host, token := range tokens {
-		if profile, ok := profiles[host]; ok && hostname == host {
-			parsed, err := url.Parse(urlRegex.ReplaceAllString(
-				*targetURL,
-				fmt.Sprintf("${Schema}%s:%s@${Hostpath}", profile.Username, token),
-			))
-			if err != nil {
-				loggerEntry.Debugf("Failed to authenticate: %s, %v", *targetURL, err)
-				continue
-			}
-
-			loggerEntry.Debugf("Authenticated: %s", *targetURL)
-			*targetURL = parsed.String()
-
-			return
-		}
-	}
-
-	util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, AuthenticationFailed, *targetURL, hostname))
-}
-
 // Remove local repositories which are not enlisted.
 func (conf Configuration) Cleanup() {
 	c := util.Console()
@@ -245,10 +306,23 @@ func (conf *Configuration) Copy() *Configuration {
 		BaseDirectory:         conf.BaseDirectory,
 		AbsoluteDirectoryPath: conf.AbsoluteDirectoryPath,
 		Profiles:              make(Profiles, len(conf.Profiles)),
+		Hosts:                 conf.Hosts.Copy(),
+		AutoStash:             conf.AutoStash,
+		CloneDepth:            conf.CloneDepth,
 		Concurrency:           conf.Concurrency,
+		DirectoryTemplate:     conf.DirectoryTemplate,
+		FetchPolicy:           conf.FetchPolicy,
+		OfflineStatus:         conf.OfflineStatus,
+		PullStrategy:          conf.PullStrategy,
+		SingleBranch:          conf.SingleBranch,
+		SSHKeyFile:            conf.SSHKeyFile,
+		SSHKnownHosts:         conf.SSHKnownHosts,
 		SubDirectories:        conf.SubDirectories,
 		SizeLimit:             conf.SizeLimit,
+		Tags:                  conf.Tags,
 		Timeout:               conf.Timeout,
+		TokenSources:          conf.TokenSources.Copy(),
+		Transport:             conf.Transport,
 		Included:              make([]string, len(conf.Included)),
 		Excluded:              make([]string, len(conf.Excluded)),
 		Repositories:          make(Repositories, len(conf.Repositories)),
@@ -282,13 +356,7 @@ func (conf Configuration) Display(format, output string, export bool, filters []
 	}
 
 	if len(filters) > 0 {
-		var repositories []Repository
-		for _, repository := range conf.Repositories {
-			if util.PatternList(filters).GlobMatch(util.StripPathPrefix(repository.Directory, 1)) {
-				repositories = append(repositories, repository)
-			}
-		}
-		conf.Repositories = repositories
+		conf.Repositories = conf.Repositories.Match(filters)
 	}
 
 	go func() {
@@ -442,6 +510,269 @@ func (conf Configuration) GeneralizeURL(targetURL *string) {
 	loggerEntry.Debugf("Generalized: %s", *targetURL)
 }
 
+// GetAuthMethod retrieves authentication to be supplied at runtime for given remote URL of given repository.
+// The profile owning the repository is used, unless the remote URL points to another host.
+// For HTTP(S) URLs, basic authentication (username and personal access token) is used.
+// For SSH URLs, the key file configured for the profile is used, or ssh-agent if there is none.
+// No authentication is returned for URLs of other transports.
+// In the case, no matching token can be found for given HTTP(S) URL, emit message and exit.
+func (conf *Configuration) GetAuthMethod(repo Repository, targetURL string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(targetURL)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, found := conf.GetProfile(repo)
+	if !found || profile.Host != endpoint.Host {
+		profile, found = conf.Profiles.ForHost(endpoint.Host)
+	}
+
+	switch endpoint.Protocol {
+
+	case "http", "https":
+		if token, ok := conf.GetToken(profile); found && ok {
+			loggerEntry.Debugf("Authenticated: %s", profile.Key())
+			username := profile.Username
+			if strings.HasSuffix(username, "[bot]") {
+				username = gitHubAppUsername
+			}
+
+			return &http.BasicAuth{Username: username, Password: token}, nil
+		}
+
+		conf.GeneralizeURL(&targetURL)
+		util.PrintlnAndExit("%s", util.Console().CheckColors(color.RedString, AuthenticationFailed, targetURL, endpoint.Host))
+		return nil, nil
+
+	case "ssh":
+		keyFile, knownHosts := conf.SSHKeyFile, conf.SSHKnownHosts
+		if found && profile.SSHKeyFile != "" {
+			keyFile = profile.SSHKeyFile
+		}
+
+		if knownHosts != "" {
+			util.PathSanitize(&knownHosts)
+		}
+
+		var auth ssh.AuthMethod
+		if keyFile != "" {
+			util.PathSanitize(&keyFile)
+			keys, err := ssh.NewPublicKeysFromFile(endpoint.User, keyFile, "")
+			if err != nil {
+				return nil, fmt.Errorf("ssh key %s: %w", keyFile, err)
+			}
+
+			if knownHosts != "" {
+				if keys.HostKeyCallback, err = ssh.NewKnownHostsCallback(knownHosts); err != nil {
+					return nil, err
+				}
+			}
+
+			auth = keys
+
+		} else {
+			agent, err := ssh.NewSSHAgentAuth(endpoint.User)
+			if err != nil {
+				return nil, err
+			}
+
+			if knownHosts != "" {
+				if agent.HostKeyCallback, err = ssh.NewKnownHostsCallback(knownHosts); err != nil {
+					return nil, err
+				}
+			}
+
+			auth = agent
+
+		}
+
+		loggerEntry.Debugf("Authenticated: %s (%s)", endpoint.Host, auth.Name())
+		return auth, nil
+
+	default:
+		loggerEntry.Debugf("Got URL of unsupported transport: %q", endpoint.Protocol)
+		return nil, nil
+
+	}
+}
+
+// GetCloneDepth retrieves clone depth for given repository ("0": full history).
+// Repository specific depth takes precedence over the configured one.
+func (conf Configuration) GetCloneDepth(repo Repository) int {
+	if repo.CloneDepth != nil {
+		return *repo.CloneDepth
+	}
+
+	return conf.CloneDepth
+}
+
+// GetDirectory retrieves the local directory of given repository owned by given profile.
+// The directory is built from the directory template (see ValidateDirectoryTemplate).
+// Without a template, repositories are stored either in subdirectories of their owners (<owner>/<repo>),
+// or flattened (<owner>_<repo>) with the username of the profile stripped.
+func (conf Configuration) GetDirectory(profile *Profile, repo resources.Repository) string {
+	var dir string
+	switch {
+
+	case conf.DirectoryTemplate != "":
+		dir = expandDirectoryTemplate(conf.DirectoryTemplate, profile, repo)
+
+	case conf.SubDirectories:
+		dir = repo.FullName
+
+	default:
+		dir = strings.ReplaceAll(repo.FullName, "/", "_")
+		dir = strings.Replace(dir, profile.Username+"_", "", 1)
+
+	}
+
+	dir = filepath.Join(conf.BaseDirectory, filepath.FromSlash(dir))
+	util.PathSanitize(&dir)
+
+	return dir
+}
+
+// GetFetchPolicy retrieves configured fetch policy (defaults to FetchPolicyTracking).
+func (conf Configuration) GetFetchPolicy() string {
+	if conf.FetchPolicy == "" {
+		return FetchPolicyTracking
+	}
+
+	return conf.FetchPolicy
+}
+
+// GetParentURL retrieves URL of the parent repository using the transport configured for given repository.
+// If no SSH URL is known (configuration created by a previous version), the HTTPS URL is used.
+func (conf Configuration) GetParentURL(repo Repository) string {
+	if conf.GetTransport(repo) == TransportSSH && repo.ParentSSHURL != "" {
+		return repo.ParentSSHURL
+	}
+
+	return repo.ParentURL
+}
+
+// GetProfile retrieves the profile owning given repository.
+// For repositories configured by previous versions, the first profile of the host of the repository is used.
+func (conf Configuration) GetProfile(repo Repository) (Profile, bool) {
+	if profile, ok := conf.Profiles.ToMap()[repo.Profile]; ok {
+		return profile, true
+	}
+
+	return conf.Profiles.ForHost(util.GetHostnameFromPath(repo.URL))
+}
+
+// GetPullStrategy retrieves pull strategy for given repository.
+// Repository specific strategy takes precedence over the configured one (defaults to PullStrategyFastForwardOnly).
+func (conf Configuration) GetPullStrategy(repo Repository) string {
+	switch {
+
+	case repo.PullStrategy != "":
+		return repo.PullStrategy
+
+	case conf.PullStrategy != "":
+		return conf.PullStrategy
+
+	default:
+		return PullStrategyFastForwardOnly
+
+	}
+}
+
+// GetRemoteURL retrieves URL of given repository using the transport configured for it.
+// If no SSH URL is known (configuration created by a previous version), the HTTPS URL is used.
+func (conf Configuration) GetRemoteURL(repo Repository) string {
+	if conf.GetTransport(repo) == TransportSSH && repo.SSHURL != "" {
+		return repo.SSHURL
+	}
+
+	return repo.URL
+}
+
+// GetSingleBranch retrieves whether only the default branch of given repository should be cloned.
+// Repository specific setting takes precedence over the configured one.
+func (conf Configuration) GetSingleBranch(repo Repository) bool {
+	if repo.SingleBranch != nil {
+		return *repo.SingleBranch
+	}
+
+	return conf.SingleBranch
+}
+
+// GetTagMode retrieves tag mode for given repository (empty, if the default of git should be used).
+// Repository specific tag mode takes precedence over the configured one.
+func (conf Configuration) GetTagMode(repo Repository) string {
+	if repo.Tags != "" {
+		return repo.Tags
+	}
+
+	return conf.Tags
+}
+
+// GetTransport retrieves transport for given repository.
+// Transport configured for the profile owning the repository takes precedence over the configured one (defaults to TransportHTTPS).
+func (conf Configuration) GetTransport(repo Repository) string {
+	profile, ok := conf.GetProfile(repo)
+	switch {
+
+	case ok && profile.Transport != "":
+		return profile.Transport
+
+	case conf.Transport != "":
+		return conf.Transport
+
+	default:
+		return TransportHTTPS
+
+	}
+}
+
+// GetToken retrieves authentication token for given profile.
+// Token mapped to the host only is used as fallback.
+func (conf *Configuration) GetToken(profile Profile) (string, bool) {
+	tokens := conf.GetTokens()
+	if token, ok := tokens[profile.Key()]; ok {
+		return token, true
+	}
+
+	token, ok := tokens[profile.Host]
+	return token, ok
+}
+
+// GetTokens retrieves authentication tokens of all configured token sources: <username>@<host> => <token>.
+// Tokens, whose account is not known before the user is retrieved, are mapped to <host> only.
+// Failing token sources are skipped.
+// Tokens are resolved once per configuration, the returned map must not be modified.
+func (conf *Configuration) GetTokens() map[string]string {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	if conf.tokens != nil {
+		return conf.tokens
+	}
+
+	transport, err := conf.Hosts.Transport()
+	if err != nil {
+		loggerEntry.Warnf("Failed to apply network settings of hosts: %v", err)
+	}
+
+	tokens := make(map[string]string)
+	for _, source := range conf.TokenSources.List(transport) {
+		sourceTokens, err := source.Tokens()
+		if err != nil {
+			loggerEntry.Warnf("Failed to retrieve tokens from %s: %v", source.Name(), err)
+			continue
+		}
+
+		loggerEntry.Debugf("Retrieved %d tokens from %s", len(sourceTokens), source.Name())
+		for account, token := range sourceTokens {
+			tokens[account] = token
+		}
+	}
+
+	conf.tokens = tokens
+	return tokens
+}
+
 // Produce progressbar description considering the length of the repository with the longest name.
 func (conf *Configuration) GetProgressbarDescriptionForVerb(verb string, repo Repository) string {
 	trim := func(in string) string {
@@ -461,17 +792,36 @@ func (conf Configuration) ListUntracked() (untracked []string) {
 	util.PathSanitize(&conf.BaseDirectory)
 	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
 
-	files := supererrors.ExceptFn(supererrors.W(filepath.Glob(conf.BaseDirectory + "/*")))
-	if conf.SubDirectories {
-		parents := supererrors.ExceptFn(supererrors.W(filepath.Glob(conf.BaseDirectory + "/*/*")))
-		files = append(files, parents...)
-	}
+	// directories are descended as long as they contain repositories, regardless of the depth of the layout
+	supererrors.Except(filepath.WalkDir(conf.BaseDirectory, func(path string, entry fs.DirEntry, err error) error {
+		switch {
+
+		case err != nil:
+			return err
+
+		case path == conf.BaseDirectory:
+			return nil
+
+		// hidden files are not considered
+		case strings.HasPrefix(entry.Name(), "."):
+			return skipEntry(entry)
 
-	for _, f := range files {
-		if !isRepoDir(f, conf.Repositories) {
-			untracked = append(untracked, f)
 		}
-	}
+
+		switch isRepo, isParent := isRepoDir(path, conf.Repositories); {
+
+		case isRepo:
+			return skipEntry(entry)
+
+		case isParent && entry.IsDir():
+			return nil
+
+		default:
+			untracked = append(untracked, path)
+			return skipEntry(entry)
+
+		}
+	}), os.ErrNotExist)
 
 	return
 }
@@ -515,23 +865,26 @@ func (conf Configuration) Remove(purge bool) {
 	defer util.Chdir(conf.AbsoluteDirectoryPath).Popd()
 
 	bar := util.NewProgressbar(len(conf.Repositories))
-	subDirectories := make(map[string]bool)
+	parents := make(map[string]bool)
 	for _, repo := range conf.Repositories {
 		_ = bar.Describe("%s", c.CheckColors(color.RedString, "%s", conf.GetProgressbarDescriptionForVerb("Removing", repo)))
 		supererrors.Except(os.RemoveAll(repo.Directory), os.ErrNotExist)
 		_ = bar.Inc()
 
-		if conf.SubDirectories {
-			subDirectories[filepath.Dir(repo.Directory)] = true
+		for parent := filepath.Dir(repo.Directory); parent != "." && parent != conf.BaseDirectory; parent = filepath.Dir(parent) {
+			parents[parent] = true
 		}
 	}
 
 	if conf.BaseDirectory != "." {
 		supererrors.Except(os.RemoveAll(conf.BaseDirectory), os.ErrNotExist)
 
-	} else if conf.SubDirectories {
-		for folder := range subDirectories {
-			supererrors.Except(os.Remove(folder), os.ErrNotExist)
+	} else {
+		// parent directories are removed deepest first, as long as they are empty
+		for _, folder := range slices.Backward(slices.Sorted(maps.Keys(parents))) {
+			if entries, err := os.ReadDir(folder); err == nil && len(entries) == 0 {
+				supererrors.Except(os.Remove(folder), os.ErrNotExist)
+			}
 		}
 
 	}
@@ -547,13 +900,27 @@ func (conf *Configuration) Overwrite(from *Configuration) {
 	}
 
 	conf.BaseDirectory = from.BaseDirectory
+	conf.CloneDepth = from.CloneDepth
 	conf.AbsoluteDirectoryPath = from.AbsoluteDirectoryPath
 	conf.SubDirectories = from.SubDirectories
+	conf.SingleBranch = from.SingleBranch
 	conf.SizeLimit = from.SizeLimit
+	conf.AutoStash = from.AutoStash
 	conf.Concurrency = from.Concurrency
+	conf.DirectoryTemplate = from.DirectoryTemplate
+	conf.FetchPolicy = from.FetchPolicy
+	conf.OfflineStatus = from.OfflineStatus
+	conf.PullStrategy = from.PullStrategy
+	conf.Tags = from.Tags
+	conf.SSHKeyFile = from.SSHKeyFile
+	conf.SSHKnownHosts = from.SSHKnownHosts
 	conf.Timeout = from.Timeout
+	conf.TokenSources = from.TokenSources
+	conf.tokens = nil
+	conf.Transport = from.Transport
 	conf.Excluded = from.Excluded
 	conf.Included = from.Included
+	conf.Hosts = from.Hosts
 
 	if len(from.Profiles) > 0 {
 		conf.Profiles = from.Profiles
@@ -1,6 +1,8 @@
 package configfile
 
 import (
+	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -30,34 +32,34 @@ func GetRepositorySlugFromURL(repo Repository) string {
 	return strings.TrimSuffix(strings.TrimPrefix(parsed.Path, "/"), filepath.Ext(parsed.Path))
 }
 
-// Retrieve all authentication tokens for each host from GitHub CLI.
-func GetTokens() map[string]string {
-	tokens := make(map[string]string)
+// Check if existing directory is enlisted as repository (isRepo),
+// or if it contains enlisted repositories at any depth (isParent).
+func isRepoDir(path string, repos []Repository) (isRepo, isParent bool) {
+	util.PathSanitize(&path)
+	for _, r := range repos {
+		util.PathSanitize(&r.Directory)
+		switch {
 
-	for _, host := range GetHosts() {
-		host = util.GetHostnameFromPath(host)
-		loggerEntry.Debugf("Retrieving token for host: %s", host)
+		case r.Directory == path:
+			return true, false
 
-		token, _ := auth.TokenForHost(host)
-		loggerEntry.Debugf("Retrieved token: %t", len(token) > 0)
+		case strings.HasPrefix(r.Directory, path+"/"):
+			isParent = true
 
-		tokens[host] = token
+		}
 	}
 
-	return tokens
+	return false, isParent
 }
 
-// Check if existing directory is enlisted as GitHub repository.
-func isRepoDir(path string, repos []Repository) bool {
-	util.PathSanitize(&path)
-	for _, r := range repos {
-		util.PathSanitize(&r.Directory)
-		if strings.HasPrefix(r.Directory+"/", path+"/") {
-			return true
-		}
+// skipEntry skips given directory when walking a file tree.
+// Files are not skipped, since it would skip the remaining files of the containing directory.
+func skipEntry(entry fs.DirEntry) error {
+	if entry.IsDir() {
+		return fs.SkipDir
 	}
 
-	return false
+	return nil
 }
 
 // Create progressbar for binary data stream (unknown length).
@@ -77,6 +79,28 @@ func newBinaryProgressbar() *util.Progressbar {
 	)
 }
 
+// Confirm asks for confirmation of the changes in interactive terminals.
+// Empty changes and changes in non-interactive terminals are confirmed implicitly.
+func (d RepositoriesDiff) Confirm() bool {
+	c := util.Console()
+	if d.Empty() || !c.IsTerminal(true, true, true) {
+		return true
+	}
+
+	confirmed := supererrors.ExceptFn(supererrors.W(
+		prompt.Confirm(
+			fmt.Sprintf("Save the configuration with %d added, %d removed and %d changed repositories?", len(d.Added), len(d.Removed), len(d.Changed)),
+			false,
+		),
+	), terminal.InterruptErr)
+
+	if supererrors.LastErrorWas(terminal.InterruptErr) {
+		os.Exit(0)
+	}
+
+	return confirmed
+}
+
 // Open links in browser.
 func OpenLins(links []string) {
 	c := util.Console()
@@ -0,0 +1,34 @@
+package configfile
+
+import "testing"
+
+func TestIsRepoDir(t *testing.T) {
+	repos := []Repository{
+		{Directory: "/base/example.com/octocat/hello-world"},
+		{Directory: "/base/example.com/hubot/spoon-knife"},
+		{Directory: "/base/flat"},
+	}
+
+	for _, tt := range []struct {
+		name         string
+		args         string
+		wantIsRepo   bool
+		wantIsParent bool
+	}{
+		{"test#1", "/base/example.com/octocat/hello-world", true, false},
+		{"test#2", "/base/example.com/octocat", false, true},
+		{"test#3", "/base/example.com", false, true},
+		{"test#4", "/base/flat", true, false},
+		{"test#5", "/base/example.com/octocat/hello", false, false},
+		{"test#6", "/base/example.com/octocat/hello-world/nested", false, false},
+		{"test#7", "/base/example.org", false, false},
+		{"test#8", "/base/fl", false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			isRepo, isParent := isRepoDir(tt.args, repos)
+			if isRepo != tt.wantIsRepo || isParent != tt.wantIsParent {
+				t.Errorf("isRepoDir(%q) failed: got: %t, %t, want: %t, %t", tt.args, isRepo, isParent, tt.wantIsRepo, tt.wantIsParent)
+			}
+		})
+	}
+}
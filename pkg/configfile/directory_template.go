@@ -0,0 +1,59 @@
+package configfile
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+)
+
+// Placeholders supported by directory templates.
+const (
+	DirectoryPlaceholderHost  = "{host}"  // host of the profile (ports are separated by "_")
+	DirectoryPlaceholderOwner = "{owner}" // owner of the repository (user or organization)
+	DirectoryPlaceholderRepo  = "{repo}"  // name of the repository
+	DirectoryPlaceholderUser  = "{user}"  // username of the profile owning the repository
+)
+
+// Matches placeholders of directory templates.
+var directoryPlaceholderRegex = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateDirectoryTemplate checks whether given directory template is applicable.
+// The template is a slash separated path relative to the base directory, which must contain the name of the repository.
+// An empty template is valid (see Configuration.GetDirectory).
+func ValidateDirectoryTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+
+	supported := []string{DirectoryPlaceholderHost, DirectoryPlaceholderOwner, DirectoryPlaceholderRepo, DirectoryPlaceholderUser}
+	for _, placeholder := range directoryPlaceholderRegex.FindAllString(template, -1) {
+		if !slices.Contains(supported, placeholder) {
+			return fmt.Errorf("unsupported placeholder %s in directory template %q, supported placeholders: [%s]", placeholder, template, strings.Join(supported, ", "))
+		}
+	}
+
+	if !strings.Contains(template, DirectoryPlaceholderRepo) {
+		return fmt.Errorf("directory template %q must contain %s", template, DirectoryPlaceholderRepo)
+	}
+
+	if path.IsAbs(template) || slices.Contains(strings.Split(path.Clean(template), "/"), "..") {
+		return fmt.Errorf("directory template %q must be relative to the base directory", template)
+	}
+
+	return nil
+}
+
+// expandDirectoryTemplate expands the placeholders of given directory template for a repository owned by given profile.
+func expandDirectoryTemplate(template string, profile *Profile, repo resources.Repository) string {
+	owner, name, _ := strings.Cut(repo.FullName, "/")
+	return path.Clean(strings.NewReplacer(
+		DirectoryPlaceholderHost, strings.ReplaceAll(profile.Host, ":", "_"),
+		DirectoryPlaceholderOwner, owner,
+		DirectoryPlaceholderRepo, name,
+		DirectoryPlaceholderUser, profile.Username,
+	).Replace(template))
+}
@@ -0,0 +1,75 @@
+package configfile
+
+import (
+	"testing"
+
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+)
+
+func TestValidateDirectoryTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		args    string
+		wantErr bool
+	}{
+		{"test#1", "", false},
+		{"test#2", "{repo}", false},
+		{"test#3", "{host}/{owner}/{repo}", false},
+		{"test#4", "{user}/{owner}_{repo}", false},
+		{"test#5", "{owner}", true},
+		{"test#6", "{owner}/{name}", true},
+		{"test#7", "/{owner}/{repo}", true},
+		{"test#8", "../{owner}/{repo}", true},
+		{"test#9", "{owner}/../../{repo}", true},
+		{"test#10", "{owner}/..{repo}", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDirectoryTemplate(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDirectoryTemplate(%q) failed: got error: %v, want error: %t", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpandDirectoryTemplate(t *testing.T) {
+	profile := &Profile{Host: "example.com:8443", Username: "octocat"}
+	for _, tt := range []struct {
+		name     string
+		template string
+		repo     string
+		want     string
+	}{
+		{"test#1", "{repo}", "hubot/hello-world", "hello-world"},
+		{"test#2", "{owner}/{repo}", "hubot/hello-world", "hubot/hello-world"},
+		{"test#3", "{host}/{owner}/{repo}", "hubot/hello-world", "example.com_8443/hubot/hello-world"},
+		{"test#4", "{user}/{owner}-{repo}", "hubot/hello-world", "octocat/hubot-hello-world"},
+		{"test#5", "./{owner}//{repo}/", "hubot/hello-world", "hubot/hello-world"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandDirectoryTemplate(tt.template, profile, resources.Repository{FullName: tt.repo}); got != tt.want {
+				t.Errorf("expandDirectoryTemplate(%q, %q) failed: got: %q, want: %q", tt.template, tt.repo, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigurationGetDirectory(t *testing.T) {
+	profile := &Profile{Host: "example.com", Username: "octocat"}
+	for _, tt := range []struct {
+		name string
+		conf Configuration
+		repo string
+		want string
+	}{
+		{"test#1", Configuration{BaseDirectory: "/base"}, "octocat/hello-world", "/base/hello-world"},
+		{"test#2", Configuration{BaseDirectory: "/base"}, "hubot/hello-world", "/base/hubot_hello-world"},
+		{"test#3", Configuration{BaseDirectory: "/base", SubDirectories: true}, "hubot/hello-world", "/base/hubot/hello-world"},
+		{"test#4", Configuration{BaseDirectory: "/base", SubDirectories: true, DirectoryTemplate: "{host}/{owner}/{repo}"}, "hubot/hello-world", "/base/example.com/hubot/hello-world"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.conf.GetDirectory(profile, resources.Repository{FullName: tt.repo}); got != tt.want {
+				t.Errorf("GetDirectory(%q) failed: got: %q, want: %q", tt.repo, got, tt.want)
+			}
+		})
+	}
+}
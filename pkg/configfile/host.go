@@ -0,0 +1,186 @@
+package configfile
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	client "github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
+// Transports of hosts are cached for the lifetime of the process to reuse their connections.
+var hostTransports sync.Map
+
+// Host holds the network settings of a host, e.g. of a GitHub Enterprise Server behind a corporate proxy with an internal CA.
+// The settings apply to the API clients and to git transports over HTTP(S).
+type Host struct {
+	Host           string `json:"host" yaml:"host"`
+	Proxy          string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	CABundle       string `json:"caBundle,omitempty" yaml:"caBundle,omitempty"`
+	ClientCertFile string `json:"clientCertFile,omitempty" yaml:"clientCertFile,omitempty"`
+	ClientKeyFile  string `json:"clientKeyFile,omitempty" yaml:"clientKeyFile,omitempty"`
+}
+
+// Matches checks whether given hostname belongs to the host.
+// Subdomains of the API (e.g. api.github.com for github.com) belong to the host as well.
+func (h Host) Matches(hostname string) bool {
+	if name, _, err := net.SplitHostPort(hostname); err == nil {
+		hostname = name
+	}
+
+	return strings.EqualFold(hostname, h.Host) || strings.EqualFold(hostname, "api."+h.Host)
+}
+
+// Transport creates an HTTP transport applying the network settings of the host.
+// The CA bundle complements the certificates of the system.
+// The client key is expected to be contained in the client certificate file, unless provided separately.
+func (h Host) Transport() (*http.Transport, error) {
+	if cached, ok := hostTransports.Load(h); ok {
+		return cached.(*http.Transport), nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if h.Proxy != "" {
+		proxy, err := url.Parse(h.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy of %s: %w", h.Host, err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if h.CABundle != "" {
+		caBundle := h.CABundle
+		util.PathSanitize(&caBundle)
+		raw, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("ca bundle of %s: %w", h.Host, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(raw) {
+			return nil, fmt.Errorf("ca bundle of %s: no certificates found in %s", h.Host, caBundle)
+		}
+
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if h.ClientCertFile != "" {
+		certFile, keyFile := h.ClientCertFile, h.ClientKeyFile
+		if keyFile == "" {
+			keyFile = certFile
+		}
+
+		util.PathSanitize(&certFile, &keyFile)
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate of %s: %w", h.Host, err)
+		}
+
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	actual, _ := hostTransports.LoadOrStore(h, transport)
+	return actual.(*http.Transport), nil
+}
+
+// Hosts holds the network settings of multiple hosts.
+type Hosts []Host
+
+// Copy host settings.
+func (h Hosts) Copy() Hosts {
+	if h == nil {
+		return nil
+	}
+
+	n := make(Hosts, len(h))
+	_ = copy(n, h)
+
+	return n
+}
+
+// ForHost retrieves the settings of given hostname (see Host.Matches).
+func (h Hosts) ForHost(hostname string) (Host, bool) {
+	for _, own := range h {
+		if own.Matches(hostname) {
+			return own, true
+		}
+	}
+
+	return Host{}, false
+}
+
+// Get retrieves the settings of given host for modification, they are appended if not present yet.
+func (h *Hosts) Get(host string) *Host {
+	for i := range *h {
+		if strings.EqualFold((*h)[i].Host, host) {
+			return &(*h)[i]
+		}
+	}
+
+	*h = append(*h, Host{Host: host})
+	return &(*h)[len(*h)-1]
+}
+
+// Transport creates an HTTP transport dispatching requests to the transports of the hosts they are sent to.
+// Requests to hosts without settings are sent through the default transport.
+// No transport is created, if there are no settings.
+func (h Hosts) Transport() (http.RoundTripper, error) {
+	if len(h) == 0 {
+		return nil, nil
+	}
+
+	transports := make(map[string]http.RoundTripper, len(h))
+	for _, host := range h {
+		transport, err := host.Transport()
+		if err != nil {
+			return nil, err
+		}
+
+		transports[strings.ToLower(host.Host)] = transport
+	}
+
+	return hostTransport{hosts: h, transports: transports}, nil
+}
+
+// InstallGitTransport makes git transports over HTTP(S) apply the network settings of the hosts.
+func (h Hosts) InstallGitTransport() error {
+	transport, err := h.Transport()
+	if err != nil || transport == nil {
+		return err
+	}
+
+	gitClient := githttp.NewClient(&http.Client{Transport: transport})
+	client.InstallProtocol("http", gitClient)
+	client.InstallProtocol("https", gitClient)
+
+	return nil
+}
+
+// hostTransport dispatches requests to the transports of the hosts they are sent to.
+type hostTransport struct {
+	hosts      Hosts
+	transports map[string]http.RoundTripper
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if host, ok := t.hosts.ForHost(req.URL.Host); ok {
+		return t.transports[strings.ToLower(host.Host)].RoundTrip(req)
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
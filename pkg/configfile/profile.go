@@ -9,10 +9,13 @@ import (
 
 // Profile holds the context of authenticated user profile.
 type Profile struct {
-	Username string `json:"username" yaml:"username"`
-	Fullname string `json:"fullname" yaml:"fullname"`
-	Email    string `json:"email,omitempty" yaml:"email,omitempty"`
-	Host     string `json:"host" yaml:"host"`
+	Username   string `json:"username" yaml:"username"`
+	Fullname   string `json:"fullname" yaml:"fullname"`
+	Email      string `json:"email,omitempty" yaml:"email,omitempty"`
+	Host       string `json:"host" yaml:"host"`
+	Provider   string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Transport  string `json:"transport,omitempty" yaml:"transport,omitempty"`
+	SSHKeyFile string `json:"sshKeyFile,omitempty" yaml:"sshKeyFile,omitempty"`
 }
 
 // Create new profile for given GitHub user and host.
@@ -35,6 +38,20 @@ func NewProfile(user *resources.User, host string) *Profile {
 	return profile
 }
 
+// Key identifies profile by username and host (<username>@<host>).
+func (p Profile) Key() string {
+	return p.Username + "@" + p.Host
+}
+
+// GetProvider retrieves the provider (forge) hosting the repositories of the profile, GitHub by default.
+func (p Profile) GetProvider() string {
+	if p.Provider == "" {
+		return ProviderGitHub
+	}
+
+	return p.Provider
+}
+
 type Profiles []Profile
 
 // Append profile (only if not present).
@@ -55,11 +72,36 @@ func (p Profiles) Has(profile Profile) bool {
 	return false
 }
 
-// Map profiles to hosts: <host> => <Profile>.
+// Inherit host specific settings from previously configured profiles (Username and Host are considered to be unique).
+func (p Profiles) Inherit(from Profiles) {
+	for i, own := range p {
+		for _, prev := range from {
+			if own.Host == prev.Host && own.Username == prev.Username {
+				p[i].Provider = prev.Provider
+				p[i].Transport = prev.Transport
+				p[i].SSHKeyFile = prev.SSHKeyFile
+				break
+			}
+		}
+	}
+}
+
+// ForHost retrieves the first profile of given host.
+func (p Profiles) ForHost(host string) (Profile, bool) {
+	for _, own := range p {
+		if own.Host == host {
+			return own, true
+		}
+	}
+
+	return Profile{}, false
+}
+
+// Map profiles to keys: <username>@<host> => <Profile>.
 func (p Profiles) ToMap() map[string]Profile {
 	m := make(map[string]Profile)
 	for _, profile := range p {
-		m[profile.Host] = profile
+		m[profile.Key()] = profile
 	}
 
 	return m
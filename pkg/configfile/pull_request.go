@@ -12,12 +12,15 @@ type PullRequest struct {
 	Assignees  []string         `json:"assignee" yaml:"assignee"`
 	Author     string           `json:"author" yaml:"author"`
 	Base       string           `json:"base" yaml:"base"`
+	Checks     string           `json:"checks,omitempty" yaml:"checks,omitempty"`
 	ClosedAt   time.Time        `json:"closed_at" yaml:"closed_at"`
 	Error      PullRequestError `json:"error" yaml:"error"`
 	Head       string           `json:"head" yaml:"head"`
 	Labels     []string         `json:"labels" yaml:"labels"`
 	Number     int              `json:"number" yaml:"number"`
+	Profile    string           `json:"profile,omitempty" yaml:"profile,omitempty"`
 	Repository string           `json:"repository" yaml:"repository"`
+	Review     string           `json:"review,omitempty" yaml:"review,omitempty"`
 	State      string           `json:"state" yaml:"state"`
 	Title      string           `json:"title" yaml:"title"`
 	URL        string           `json:"URL" yaml:"URL"`
@@ -77,10 +80,12 @@ func PullRequestFromResponse(response resources.PullRequest) PullRequest {
 		Assignees:  []string{response.Assignee.Login},
 		Author:     response.User.Login,
 		Base:       response.Base.Ref,
+		Checks:     response.CheckStatus,
 		ClosedAt:   response.ClosedAt,
 		Head:       response.Head.Ref,
 		Number:     response.Number,
 		Repository: response.Repository,
+		Review:     response.ReviewDecision,
 		State:      response.State,
 		Title:      response.Title,
 		URL:        response.HTMLURL,
@@ -1,13 +1,29 @@
 package configfile
 
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
 // Repository holds a repository URL and its local directory equivalent.
 type Repository struct {
-	URL       string `json:"URL" yaml:"URL"`
-	Directory string `json:"directory" yaml:"directory"`
-	Branch    string `json:"branch" yaml:"branch"`
-	ParentURL string `json:"parentURL,omitempty" yaml:"parentURL,omitempty"`
-	Public    bool   `json:"public,omitempty" yaml:"public,omitempty"`
-	Size      string `json:"size" yaml:"size"`
+	URL          string `json:"URL" yaml:"URL"`
+	ID           int    `json:"id,omitempty" yaml:"id,omitempty"`
+	Directory    string `json:"directory" yaml:"directory"`
+	Branch       string `json:"branch" yaml:"branch"`
+	ParentURL    string `json:"parentURL,omitempty" yaml:"parentURL,omitempty"`
+	SSHURL       string `json:"sshURL,omitempty" yaml:"sshURL,omitempty"`
+	ParentSSHURL string `json:"parentSSHURL,omitempty" yaml:"parentSSHURL,omitempty"`
+	Profile      string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Public       bool   `json:"public,omitempty" yaml:"public,omitempty"`
+	Size         string `json:"size" yaml:"size"`
+	PullStrategy string `json:"pullStrategy,omitempty" yaml:"pullStrategy,omitempty"`
+	CloneDepth   *int   `json:"cloneDepth,omitempty" yaml:"cloneDepth,omitempty"`
+	SingleBranch *bool  `json:"singleBranch,omitempty" yaml:"singleBranch,omitempty"`
+	Tags         string `json:"tags,omitempty" yaml:"tags,omitempty"`
 }
 
 type Repositories []Repository
@@ -30,6 +46,67 @@ func (r Repositories) Has(repo Repository) bool {
 	return false
 }
 
+// Inherit repository specific settings from previously configured repositories (URL is considered to be unique).
+// Renamed or transferred repositories inherit the settings as well (see Repositories.Renames).
+func (r Repositories) Inherit(from Repositories) {
+	previous := from.matcher()
+	for i, own := range r {
+		if prev, ok := previous(own); ok {
+			r[i].PullStrategy = prev.PullStrategy
+			r[i].CloneDepth = prev.CloneDepth
+			r[i].SingleBranch = prev.SingleBranch
+			r[i].Tags = prev.Tags
+		}
+	}
+}
+
+// matcher indexes the repositories to look up previously configured equivalents of repositories.
+// Repositories are matched by their ID on the same host, so that renamed or transferred repositories are found as well.
+// Repositories configured by previous versions (without ID) are matched by URL.
+func (r Repositories) matcher() func(Repository) (Repository, bool) {
+	key := func(repo Repository) string { return fmt.Sprintf("%s/%d", util.GetHostnameFromPath(repo.URL), repo.ID) }
+
+	byID, byURL := make(map[string]Repository, len(r)), make(map[string]Repository, len(r))
+	for _, own := range r {
+		if own.ID != 0 {
+			byID[key(own)] = own
+		}
+
+		byURL[own.URL] = own
+	}
+
+	return func(repo Repository) (Repository, bool) {
+		if repo.ID != 0 {
+			if prev, ok := byID[key(repo)]; ok {
+				return prev, true
+			}
+		}
+
+		prev, ok := byURL[repo.URL]
+		return prev, ok
+	}
+}
+
+// RepositoryRename represents a repository, whose directory changed since it has been configured,
+// e.g. since it has been renamed or transferred to another owner.
+type RepositoryRename struct {
+	From Repository
+	To   Repository
+}
+
+// Renames detects repositories, whose directories changed in comparison to previously configured repositories.
+func (r Repositories) Renames(from Repositories) []RepositoryRename {
+	previous := from.matcher()
+	var renames []RepositoryRename
+	for _, own := range r {
+		if prev, ok := previous(own); ok && prev.Directory != own.Directory {
+			renames = append(renames, RepositoryRename{From: prev, To: own})
+		}
+	}
+
+	return renames
+}
+
 // Get the name of the repository with the longest name.
 func (r Repositories) LongestName() string {
 	var name string
@@ -41,3 +118,126 @@ func (r Repositories) LongestName() string {
 
 	return name
 }
+
+// Match repositories against glob patterns (directory and its parent directory are considered).
+// If no patterns are provided, all repositories are returned.
+func (r Repositories) Match(patterns []string) Repositories {
+	if len(patterns) == 0 {
+		return r
+	}
+
+	var matched Repositories
+	for _, own := range r {
+		if util.PatternList(patterns).GlobMatch(util.StripPathPrefix(own.Directory, 1)) {
+			matched = append(matched, own)
+		}
+	}
+
+	return matched
+}
+
+// Collisions lists repositories, whose directories coincide or are nested into each other: <directory> => <URLs>.
+// Such repositories cannot be cloned side by side, e.g. repositories of the same name on different hosts.
+func (r Repositories) Collisions() map[string][]string {
+	directories := make(map[string][]string, len(r))
+	for _, own := range r {
+		dir := own.Directory
+		util.PathSanitize(&dir)
+		directories[dir] = append(directories[dir], own.URL)
+	}
+
+	collisions := make(map[string][]string)
+	for dir, urls := range directories {
+		if len(urls) > 1 {
+			collisions[dir] = append(collisions[dir], urls...)
+		}
+
+		for parent := path.Dir(dir); parent != "." && parent != "/"; parent = path.Dir(parent) {
+			if parentURLs, ok := directories[parent]; ok {
+				collisions[parent] = append(collisions[parent], parentURLs...)
+				collisions[parent] = append(collisions[parent], urls...)
+			}
+		}
+	}
+
+	for dir := range collisions {
+		slices.Sort(collisions[dir])
+		collisions[dir] = slices.Compact(collisions[dir])
+	}
+
+	return collisions
+}
+
+// RepositoryChange represents a changed attribute of a repository.
+type RepositoryChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RepositoryDiff represents the changed attributes of a repository: <attribute> => <change>.
+type RepositoryDiff struct {
+	URL       string                      `json:"URL"`
+	Directory string                      `json:"directory"`
+	Changes   map[string]RepositoryChange `json:"changes"`
+}
+
+// RepositoriesDiff represents the difference between the repositories of two configurations.
+type RepositoriesDiff struct {
+	Added   Repositories     `json:"added"`
+	Removed Repositories     `json:"removed"`
+	Changed []RepositoryDiff `json:"changed"`
+}
+
+// Empty checks whether there is no difference.
+func (d RepositoriesDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the repositories with previously configured repositories.
+// Renamed or transferred repositories are considered changed (see Repositories.Renames).
+// The branch, visibility, URL, parent URL and directory of repositories are compared.
+func (r Repositories) Diff(from Repositories) RepositoriesDiff {
+	diff := RepositoriesDiff{Added: Repositories{}, Removed: Repositories{}, Changed: []RepositoryDiff{}}
+	visibility := func(repo Repository) string {
+		if repo.Public {
+			return "public"
+		}
+
+		return "private"
+	}
+
+	previous, matched := from.matcher(), make(map[string]bool)
+	for _, own := range r {
+		prev, ok := previous(own)
+		if !ok {
+			diff.Added = append(diff.Added, own)
+			continue
+		}
+
+		matched[prev.URL] = true
+		changes := make(map[string]RepositoryChange)
+		for attribute, values := range map[string][2]string{
+			"branch":     {prev.Branch, own.Branch},
+			"directory":  {prev.Directory, own.Directory},
+			"parentURL":  {prev.ParentURL, own.ParentURL},
+			"URL":        {prev.URL, own.URL},
+			"visibility": {visibility(prev), visibility(own)},
+		} {
+			if values[0] != values[1] {
+				changes[attribute] = RepositoryChange{From: values[0], To: values[1]}
+			}
+		}
+
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, RepositoryDiff{URL: own.URL, Directory: own.Directory, Changes: changes})
+		}
+	}
+
+	for _, prev := range from {
+		if !matched[prev.URL] {
+			diff.Removed = append(diff.Removed, prev)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,148 @@
+package configfile
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRepositoriesCollisions(t *testing.T) {
+	repo := func(dir, url string) Repository { return Repository{Directory: dir, URL: url} }
+
+	for _, tt := range []struct {
+		name string
+		args Repositories
+		want map[string][]string
+	}{
+		{"test#1", Repositories{repo("/base/a", "https://example.com/a"), repo("/base/b", "https://example.com/b")}, map[string][]string{}},
+		{"test#2", Repositories{repo("/base/a", "https://example.com/b"), repo("/base/a", "https://example.com/a")},
+			map[string][]string{"/base/a": {"https://example.com/a", "https://example.com/b"}}},
+		{"test#3", Repositories{repo("/base/a", "https://example.com/a"), repo("/base/a/b", "https://example.com/b")},
+			map[string][]string{"/base/a": {"https://example.com/a", "https://example.com/b"}}},
+		{"test#4", Repositories{repo("/base/a", "https://example.com/a"), repo("/base/a/b/c", "https://example.com/c")},
+			map[string][]string{"/base/a": {"https://example.com/a", "https://example.com/c"}}},
+		{"test#5", Repositories{repo("/base/ab", "https://example.com/ab"), repo("/base/a/b", "https://example.com/b"), repo("/base/a-b", "https://example.com/a-b")},
+			map[string][]string{}},
+		{"test#6", Repositories{repo("/base/a", "https://example.com/a"), repo("/base/a/b", "https://example.com/b"), repo("/base/a/b", "https://example.com/c")},
+			map[string][]string{
+				"/base/a":   {"https://example.com/a", "https://example.com/b", "https://example.com/c"},
+				"/base/a/b": {"https://example.com/b", "https://example.com/c"},
+			}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.args.Collisions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Collisions() failed: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoriesRenames(t *testing.T) {
+	previous := Repositories{
+		{ID: 1, Directory: "/base/octocat/hello-world", URL: "https://example.com/octocat/hello-world.git"},
+		{ID: 2, Directory: "/base/octocat/spoon-knife", URL: "https://example.com/octocat/spoon-knife.git"},
+		{Directory: "/base/octocat/legacy", URL: "https://example.com/octocat/legacy.git"},
+		{ID: 4, Directory: "/base/octocat/unchanged", URL: "https://example.com/octocat/unchanged.git"},
+	}
+
+	for _, tt := range []struct {
+		name string
+		args Repository
+		want []RepositoryRename
+	}{
+		// renamed repository
+		{"test#1", Repository{ID: 1, Directory: "/base/octocat/hello-universe", URL: "https://example.com/octocat/hello-universe.git"},
+			[]RepositoryRename{{From: previous[0], To: Repository{ID: 1, Directory: "/base/octocat/hello-universe", URL: "https://example.com/octocat/hello-universe.git"}}}},
+		// transferred repository
+		{"test#2", Repository{ID: 2, Directory: "/base/hubot/spoon-knife", URL: "https://example.com/hubot/spoon-knife.git"},
+			[]RepositoryRename{{From: previous[1], To: Repository{ID: 2, Directory: "/base/hubot/spoon-knife", URL: "https://example.com/hubot/spoon-knife.git"}}}},
+		// repository with the same ID on another host
+		{"test#3", Repository{ID: 1, Directory: "/base/other/hello-world", URL: "https://example.org/other/hello-world.git"}, nil},
+		// repository configured without ID is matched by URL
+		{"test#4", Repository{ID: 3, Directory: "/base/octocat_legacy", URL: "https://example.com/octocat/legacy.git"},
+			[]RepositoryRename{{From: previous[2], To: Repository{ID: 3, Directory: "/base/octocat_legacy", URL: "https://example.com/octocat/legacy.git"}}}},
+		// renamed repository configured without ID cannot be matched
+		{"test#5", Repository{ID: 3, Directory: "/base/octocat/modern", URL: "https://example.com/octocat/modern.git"}, nil},
+		{"test#6", Repository{ID: 4, Directory: "/base/octocat/unchanged", URL: "https://example.com/octocat/unchanged.git"}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Repositories{tt.args}).Renames(previous); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Renames() failed: got: %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoriesDiff(t *testing.T) {
+	previous := Repositories{
+		{ID: 1, Directory: "/base/octocat/hello-world", URL: "https://example.com/octocat/hello-world.git", Branch: "main"},
+		{ID: 2, Directory: "/base/octocat/spoon-knife", URL: "https://example.com/octocat/spoon-knife.git", Branch: "main"},
+		{ID: 3, Directory: "/base/octocat/removed", URL: "https://example.com/octocat/removed.git", Branch: "main"},
+		{Directory: "/base/octocat/legacy", URL: "https://example.com/octocat/legacy.git", Branch: "main"},
+	}
+
+	current := Repositories{
+		// unchanged, except for attributes not compared
+		{ID: 1, Directory: "/base/octocat/hello-world", URL: "https://example.com/octocat/hello-world.git", Branch: "main", Size: "1 MiB"},
+		// renamed
+		{ID: 2, Directory: "/base/hubot/spoon-fork", URL: "https://example.com/hubot/spoon-fork.git", Branch: "main"},
+		// changed, matched by URL
+		{ID: 4, Directory: "/base/octocat/legacy", URL: "https://example.com/octocat/legacy.git", Branch: "develop", ParentURL: "https://example.com/upstream/legacy.git", Public: true},
+		// added
+		{ID: 5, Directory: "/base/octocat/added", URL: "https://example.com/octocat/added.git", Branch: "main"},
+	}
+
+	got := current.Diff(previous)
+	want := RepositoriesDiff{
+		Added:   Repositories{current[3]},
+		Removed: Repositories{previous[2]},
+		Changed: []RepositoryDiff{
+			{URL: current[1].URL, Directory: current[1].Directory, Changes: map[string]RepositoryChange{
+				"directory": {From: "/base/octocat/spoon-knife", To: "/base/hubot/spoon-fork"},
+				"URL":       {From: "https://example.com/octocat/spoon-knife.git", To: "https://example.com/hubot/spoon-fork.git"},
+			}},
+			{URL: current[2].URL, Directory: current[2].Directory, Changes: map[string]RepositoryChange{
+				"branch":     {From: "main", To: "develop"},
+				"parentURL":  {From: "", To: "https://example.com/upstream/legacy.git"},
+				"visibility": {From: "private", To: "public"},
+			}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() failed: got: %+v, want: %+v", got, want)
+	}
+
+	if got.Empty() {
+		t.Error("Empty() failed: got: true, want: false")
+	}
+
+	if diff := previous.Diff(previous); !diff.Empty() {
+		t.Errorf("Empty() failed: got: %+v, want empty difference", diff)
+	}
+}
+
+func TestRepositoriesDiffJSON(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args RepositoriesDiff
+		want string
+	}{
+		{"test#1", Repositories{}.Diff(Repositories{}), `{"added":[],"removed":[],"changed":[]}`},
+		{"test#2", Repositories{{URL: "https://example.com/a.git", Directory: "/base/b", Branch: "main"}}.Diff(Repositories{{URL: "https://example.com/a.git", Directory: "/base/a", Branch: "main"}}),
+			`{"added":[],"removed":[],"changed":[{"URL":"https://example.com/a.git","directory":"/base/b","changes":{"directory":{"from":"/base/a","to":"/base/b"}}}]}`},
+		{"test#3", Repositories{{URL: "https://example.com/a.git", Directory: "/base/a", Branch: "main", Size: "1 KiB"}}.Diff(nil),
+			`{"added":[{"URL":"https://example.com/a.git","directory":"/base/a","branch":"main","size":"1 KiB"}],"removed":[],"changed":[]}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal(RepositoriesDiff) failed: got: %s, want: %s", got, tt.want)
+			}
+		})
+	}
+}
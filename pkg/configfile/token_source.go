@@ -0,0 +1,392 @@
+package configfile
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	auth "github.com/cli/go-gh/v2/pkg/auth"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
+// Username to authenticate git transports with installation tokens of GitHub Apps.
+const gitHubAppUsername = "x-access-token"
+
+// Installation tokens are refreshed, if they expire within this margin.
+const gitHubAppTokenRefreshMargin = 5 * time.Minute
+
+// Installation token sources of GitHub Apps are cached for the lifetime of the process to reuse unexpired tokens.
+var gitHubAppTokenSources sync.Map
+
+// GitHubApp holds the settings of a GitHub App installation used as token source.
+type GitHubApp struct {
+	Host           string `json:"host" yaml:"host"`
+	AppID          int64  `json:"appID" yaml:"appID"`
+	InstallationID int64  `json:"installationID" yaml:"installationID"`
+	PrivateKeyFile string `json:"privateKeyFile" yaml:"privateKeyFile"`
+}
+
+// TokenSource provides authentication tokens for accounts.
+// Tokens are mapped to <username>@<host>, or to <host> only, if the account is not known.
+type TokenSource interface {
+	Name() string
+	Tokens() (map[string]string, error)
+}
+
+// TokenSources holds the settings of token sources complementing the GitHub CLI.
+// Tokens of the GitHub CLI are superseded by tokens of credential files, environment variables and GitHub Apps (in that order).
+type TokenSources struct {
+	Environment map[string]string `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Files       []string          `json:"files,omitempty" yaml:"files,omitempty"`
+	Apps        []GitHubApp       `json:"apps,omitempty" yaml:"apps,omitempty"`
+}
+
+// Copy token sources.
+func (s TokenSources) Copy() TokenSources {
+	n := TokenSources{
+		Files: make([]string, len(s.Files)),
+		Apps:  make([]GitHubApp, len(s.Apps)),
+	}
+
+	_ = copy(n.Files, s.Files)
+	_ = copy(n.Apps, s.Apps)
+
+	if s.Environment != nil {
+		n.Environment = make(map[string]string, len(s.Environment))
+		for k, v := range s.Environment {
+			n.Environment[k] = v
+		}
+	}
+
+	return n
+}
+
+// List configured token sources in order of ascending precedence.
+// Requests of token sources are sent through given transport (the default transport is used, if nil).
+func (s TokenSources) List(transport http.RoundTripper) []TokenSource {
+	sources := []TokenSource{gitHubCLITokenSource{}}
+	for _, file := range s.Files {
+		sources = append(sources, credentialFileTokenSource(file))
+	}
+
+	if len(s.Environment) > 0 {
+		sources = append(sources, environmentTokenSource(s.Environment))
+	}
+
+	for _, app := range s.Apps {
+		source, _ := gitHubAppTokenSources.LoadOrStore(app, &gitHubAppTokenSource{GitHubApp: app})
+		source.(*gitHubAppTokenSource).setTransport(transport)
+		sources = append(sources, source.(*gitHubAppTokenSource))
+	}
+
+	return sources
+}
+
+// credentialFileTokenSource reads tokens from a credential file in the format of git-credential-store,
+// i.e. one URL per line, e.g. "https://<username>:<token>@<host>".
+type credentialFileTokenSource string
+
+func (s credentialFileTokenSource) Name() string { return "file " + string(s) }
+
+func (s credentialFileTokenSource) Tokens() (map[string]string, error) {
+	path := string(s)
+	util.PathSanitize(&path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parsed, err := url.Parse(line)
+		if err != nil || parsed.User == nil {
+			continue
+		}
+
+		token, ok := parsed.User.Password()
+		if !ok || token == "" {
+			continue
+		}
+
+		if username := parsed.User.Username(); username != "" {
+			tokens[username+"@"+parsed.Hostname()] = token
+		} else {
+			tokens[parsed.Hostname()] = token
+		}
+	}
+
+	return tokens, nil
+}
+
+// environmentTokenSource reads tokens from environment variables: <username>@<host> or <host> => <name of environment variable>.
+type environmentTokenSource map[string]string
+
+func (s environmentTokenSource) Name() string { return "environment" }
+
+func (s environmentTokenSource) Tokens() (map[string]string, error) {
+	tokens := make(map[string]string)
+	for account, variable := range s {
+		if token := os.Getenv(variable); token != "" {
+			tokens[account] = token
+		}
+	}
+
+	return tokens, nil
+}
+
+// gitHubAppTokenSource exchanges a JSON Web Token signed with the private key of a GitHub App for installation tokens.
+// Tokens are mapped to <app-slug>[bot]@<host> and refreshed shortly before they expire.
+type gitHubAppTokenSource struct {
+	GitHubApp
+	mu        sync.Mutex
+	account   string
+	token     string
+	expires   time.Time
+	transport http.RoundTripper
+}
+
+func (s *gitHubAppTokenSource) Name() string {
+	return fmt.Sprintf("app %d (installation %d)", s.AppID, s.InstallationID)
+}
+
+func (s *gitHubAppTokenSource) Tokens() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expires) > gitHubAppTokenRefreshMargin {
+		return map[string]string{s.account: s.token}, nil
+	}
+
+	jwt, err := s.signJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if s.account == "" {
+		var app struct {
+			Slug string `json:"slug"`
+		}
+
+		if err := s.request(ctx, http.MethodGet, "app", jwt, &app); err != nil {
+			return nil, err
+		}
+
+		s.account = app.Slug + "[bot]@" + s.Host
+	}
+
+	var installation struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	if err := s.request(ctx, http.MethodPost, fmt.Sprintf("app/installations/%d/access_tokens", s.InstallationID), jwt, &installation); err != nil {
+		return nil, err
+	}
+
+	loggerEntry.Debugf("Retrieved installation token for %s, expires at: %s", s.account, installation.ExpiresAt)
+	s.token, s.expires = installation.Token, installation.ExpiresAt
+	return map[string]string{s.account: s.token}, nil
+}
+
+// setTransport sets the transport used to exchange tokens.
+func (s *gitHubAppTokenSource) setTransport(transport http.RoundTripper) {
+	s.mu.Lock()
+	s.transport = transport
+	s.mu.Unlock()
+}
+
+// request sends request authenticated as GitHub App to the REST API of its host.
+func (s *gitHubAppTokenSource) request(ctx context.Context, method, path, jwt string, response any) error {
+	base := "https://" + s.Host + "/api/v3/"
+	if s.Host == "github.com" || auth.IsTenancy(s.Host) {
+		base = "https://api." + s.Host + "/"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, base+path, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := (&http.Client{Transport: s.transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// signJWT creates a JSON Web Token signed with the private key of the GitHub App (valid for 10 minutes at most).
+func (s *gitHubAppTokenSource) signJWT() (string, error) {
+	path := s.PrivateKeyFile
+	util.PathSanitize(&path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", fmt.Errorf("private key %s: no PEM data found", s.PrivateKeyFile)
+	}
+
+	var key *rsa.PrivateKey
+	if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("private key %s: %w", s.PrivateKeyFile, err)
+		}
+
+		var ok bool
+		if key, ok = parsed.(*rsa.PrivateKey); !ok {
+			return "", fmt.Errorf("private key %s: not an RSA key", s.PrivateKeyFile)
+		}
+	}
+
+	// issued in the past to allow for clock drift
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprint(s.AppID),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// gitHubCLITokenSource retrieves tokens of all accounts known to GitHub CLI.
+// A token provided by an environment variable of GitHub CLI (e.g. GH_TOKEN) supersedes the accounts of its host.
+// Tokens are retrieved once and cached for the lifetime of the process.
+type gitHubCLITokenSource struct{}
+
+func (gitHubCLITokenSource) Name() string { return "gh" }
+
+func (gitHubCLITokenSource) Tokens() (map[string]string, error) { return gitHubCLITokens(), nil }
+
+// Retrieve tokens of all accounts known to GitHub CLI.
+var gitHubCLITokens = sync.OnceValue(func() map[string]string {
+	tokens := make(map[string]string)
+	cfg, err := configReader()
+	if err != nil {
+		loggerEntry.Debugf("Failed to read GitHub CLI config: %v", err)
+	}
+
+	for _, host := range GetHosts() {
+		host = util.GetHostnameFromPath(host)
+		loggerEntry.Debugf("Retrieving tokens for host: %s", host)
+
+		if token, source := auth.TokenFromEnvOrConfig(host); token != "" && source != ghOAuthTokenKey {
+			loggerEntry.Debugf("Retrieved token from: %s", source)
+			tokens[host] = token
+			continue
+		}
+
+		var active string
+		var users []string
+		if cfg != nil {
+			active, _ = cfg.Get([]string{ghHostsKey, host, ghUserKey})
+			users, _ = cfg.Keys([]string{ghHostsKey, host, ghUsersKey})
+		}
+
+		// configuration of GitHub CLI predating multiple accounts
+		if len(users) == 0 {
+			token, _ := auth.TokenForHost(host)
+			loggerEntry.Debugf("Retrieved token for active account %q: %t", active, len(token) > 0)
+
+			if active != "" {
+				tokens[active+"@"+host] = token
+			} else {
+				tokens[host] = token
+			}
+
+			continue
+		}
+
+		for _, user := range users {
+			var token string
+			if cfg != nil {
+				token, _ = cfg.Get([]string{ghHostsKey, host, ghUsersKey, user, ghOAuthTokenKey})
+			}
+
+			switch {
+
+			case token != "":
+
+			case user == active:
+				token, _ = auth.TokenForHost(host)
+
+			default:
+				token = tokenFromGitHubCLI(host, user)
+
+			}
+
+			loggerEntry.Debugf("Retrieved token for account %q: %t", user, len(token) > 0)
+			if token != "" {
+				tokens[user+"@"+host] = token
+			}
+		}
+	}
+
+	return tokens
+})
+
+// Retrieve token of given account from GitHub CLI (supports tokens stored in system keyring).
+func tokenFromGitHubCLI(host, user string) string {
+	gh := os.Getenv("GH_PATH")
+	if gh == "" {
+		gh, _ = exec.LookPath("gh")
+	}
+
+	if gh == "" {
+		return ""
+	}
+
+	out, err := exec.Command(gh, "auth", "token", "--hostname", host, "--user", user).Output()
+	if err != nil {
+		loggerEntry.Debugf("Failed to retrieve token for account %q: %v", user, err)
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
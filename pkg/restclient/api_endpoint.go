@@ -6,6 +6,7 @@ import (
 )
 
 const (
+	installReposEp = apiEndpoint("installation/repositories")
 	orgEp          = apiEndpoint("orgs/{owner}")
 	orgReposEp     = apiEndpoint("orgs/{owner}/repos")
 	orgsEp         = apiEndpoint("organizations")
@@ -16,6 +17,7 @@ const (
 	userEp         = apiEndpoint("user")
 	userOrgsEp     = apiEndpoint("user/orgs")
 	userReposEp    = apiEndpoint("user/repos")
+	usersEp        = apiEndpoint("users/{username}")
 )
 
 // Helper for storing API endpoints.
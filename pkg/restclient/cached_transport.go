@@ -0,0 +1,347 @@
+package restclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	config "github.com/cli/go-gh/v2/pkg/config"
+)
+
+// Constants for response caching.
+const (
+	cacheDirectoryName = "gr-cache" // Name of the cache directory within the config directory of GitHub CLI
+	cacheFileExtension = ".json"    // Extension of cached responses
+	defaultCacheSize   = 100 << 20  // Maximum size of the cache (100 MiB)
+)
+
+// Default response cache, shared by the transport chains of all clients (see withTransport).
+var defaultCachedTransport = newCachedTransport(http.DefaultTransport, filepath.Join(config.ConfigDir(), cacheDirectoryName), defaultCacheSize)
+
+// cachedResponse is a response stored in the cache.
+type cachedResponse struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// cacheState is the state of a cache shared by all cached transports using it (see withTransport).
+type cacheState struct {
+	mu     sync.Mutex
+	bypass bool
+	size   int64 // running estimate of the size of the cache ("-1": not measured yet)
+}
+
+// cachedTransport wraps an http.RoundTripper to cache responses on disk.
+// Cached responses are revalidated with conditional requests (If-None-Match, If-Modified-Since),
+// since responses with status "304 Not Modified" do not count against the quota.
+// The least recently used responses are evicted, once the cache exceeds its maximum size.
+type cachedTransport struct {
+	Transport http.RoundTripper
+	Directory string
+	MaxSize   int64
+	*cacheState
+}
+
+// cacheKey determines the name of the file given request is cached in.
+// Responses are cached per token, so that they are not shared between accounts.
+func (t *cachedTransport) cacheKey(req *http.Request) string {
+	digest := sha256.Sum256([]byte(strings.Join([]string{
+		req.Method,
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get("Authorization"),
+	}, "\n")))
+
+	return filepath.Join(t.Directory, hex.EncodeToString(digest[:])+cacheFileExtension)
+}
+
+// isCacheable checks whether the response to given request may be cached.
+func (t *cachedTransport) isCacheable(req *http.Request) bool {
+	t.mu.Lock()
+	bypass := t.bypass
+	t.mu.Unlock()
+
+	switch {
+
+	case
+		bypass,
+		t.Directory == "",
+		req.Method != http.MethodGet,
+		req.Header.Get("Range") != "",
+		req.Header.Get("If-None-Match") != "",
+		req.Header.Get("If-Modified-Since") != "",
+		strings.HasSuffix(req.URL.Path, "/"+string(rateLimitEp)):
+
+		return false
+
+	default:
+		return true
+
+	}
+}
+
+// load retrieves the cached response to given request.
+func (t *cachedTransport) load(path string) (*cachedResponse, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		loggerEntry.Debugf("Failed to decode cached response %s: %v", path, err)
+		return nil, false
+	}
+
+	return &cached, true
+}
+
+// prune evicts the least recently used responses until the size of the cache is within its limit.
+// The actual size of the cache is measured, since other processes may share the cache directory.
+// The caller must hold the lock.
+func (t *cachedTransport) prune() error {
+	entries, err := os.ReadDir(t.Directory)
+	if err != nil {
+		return err
+	}
+
+	var files []fs.FileInfo
+	t.size = 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != cacheFileExtension {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		t.size += info.Size()
+		files = append(files, info)
+	}
+
+	slices.SortFunc(files, func(a, b fs.FileInfo) int { return a.ModTime().Compare(b.ModTime()) })
+	for _, info := range files {
+		if t.size <= t.MaxSize {
+			break
+		}
+
+		if err := os.Remove(filepath.Join(t.Directory, info.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		t.size -= info.Size()
+	}
+
+	return nil
+}
+
+// store writes given response into the cache.
+func (t *cachedTransport) store(path string, cached *cachedResponse) error {
+	raw, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(t.Directory, 0700); err != nil {
+		return err
+	}
+
+	// write to temporary file first, so that concurrent readers never see partial responses
+	f, err := os.CreateTemp(t.Directory, "*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(raw); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var replaced int64
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	// the cache directory is scanned only once it is (or might be) full
+	if t.size >= 0 {
+		t.size += int64(len(raw)) - replaced
+	}
+
+	if t.size < 0 || t.size > t.MaxSize {
+		return t.prune()
+	}
+
+	return nil
+}
+
+// Clear removes all cached responses and returns their number and total size.
+func (t *cachedTransport) Clear() (entries int, size int64, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	files, err := os.ReadDir(t.Directory)
+	switch {
+
+	case errors.Is(err, fs.ErrNotExist):
+		return 0, 0, nil
+
+	case err != nil:
+		return 0, 0, err
+
+	}
+
+	for _, file := range files {
+		if info, err := file.Info(); err == nil && filepath.Ext(file.Name()) == cacheFileExtension {
+			entries, size = entries+1, size+info.Size()
+		}
+	}
+
+	t.size = -1
+	return entries, size, os.RemoveAll(t.Directory)
+}
+
+// RoundTrip implements the http.RoundTripper interface, managing response caching.
+func (t *cachedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.isCacheable(req) {
+		return t.Transport.RoundTrip(req)
+	}
+
+	path := t.cacheKey(req)
+	cached, found := t.load(path)
+	if found {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	switch {
+
+	case found && resp.StatusCode == http.StatusNotModified:
+		loggerEntry.Debugf("Serving cached response for: %s", req.URL)
+		_ = resp.Body.Close()
+
+		// headers of the revalidation (e.g. rate limits) supersede the cached ones
+		header := cached.Header.Clone()
+		for key, values := range resp.Header {
+			if key != "Content-Length" {
+				header[key] = values
+			}
+		}
+
+		now := time.Now()
+		_ = os.Chtimes(path, now, now)
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode)),
+			StatusCode:    cached.StatusCode,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+			TLS:           resp.TLS,
+		}, nil
+
+	case
+		resp.StatusCode != http.StatusOK,
+		resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "",
+		strings.Contains(resp.Header.Get("Cache-Control"), "no-store"):
+
+		return resp, nil
+
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err := t.store(path, &cachedResponse{
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}); err != nil {
+		loggerEntry.Debugf("Failed to cache response for %s: %v", req.URL, err)
+	}
+
+	return resp, nil
+}
+
+// SetBypass sets the bypass flag to disable or enable caching.
+func (t *cachedTransport) SetBypass(bypass bool) {
+	t.mu.Lock()
+	t.bypass = bypass
+	t.mu.Unlock()
+}
+
+// withTransport creates a cached transport sharing the cache (directory, size, state) of t,
+// which sends requests through given transport.
+func (t *cachedTransport) withTransport(rt http.RoundTripper) *cachedTransport {
+	return &cachedTransport{
+		Transport:  rt,
+		Directory:  t.Directory,
+		MaxSize:    t.MaxSize,
+		cacheState: t.cacheState,
+	}
+}
+
+// newCachedTransport creates a new cachedTransport storing responses in given directory.
+func newCachedTransport(rt http.RoundTripper, directory string, maxSize int64) *cachedTransport {
+	return &cachedTransport{
+		Transport:  rt,
+		Directory:  directory,
+		MaxSize:    maxSize,
+		cacheState: &cacheState{size: -1},
+	}
+}
+
+// BypassCache disables (or enables) the response cache.
+func BypassCache(bypass bool) { defaultCachedTransport.SetBypass(bypass) }
+
+// ClearCache removes all cached responses and returns their number and total size.
+func ClearCache() (entries int, size int64, err error) { return defaultCachedTransport.Clear() }
@@ -0,0 +1,206 @@
+package restclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedTransport(t *testing.T) {
+	var requests, revalidations atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "42")
+
+		switch {
+
+		case strings.HasPrefix(r.URL.Path, "/uncached"):
+			_, _ = w.Write([]byte(`"uncached"`))
+
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`"` + r.URL.Path + `"`))
+
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	do := func(transport *cachedTransport, method, path, token string) (int, string, error) {
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			return 0, "", err
+		}
+
+		req.Header.Set("Authorization", "token "+token)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	t.Run("Revalidate", func(t *testing.T) {
+		requests.Store(0)
+		revalidations.Store(0)
+		transport := newCachedTransport(http.DefaultTransport, t.TempDir(), defaultCacheSize)
+		for range 3 {
+			code, body, err := do(transport, http.MethodGet, "/repos", "1234")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code != http.StatusOK || body != `"/repos"` {
+				t.Errorf("unexpected response: got %d %s, want %d %s", code, body, http.StatusOK, `"/repos"`)
+			}
+		}
+
+		if requests.Load() != 3 || revalidations.Load() != 2 {
+			t.Errorf("unexpected requests: got %d (%d revalidated), want 3 (2 revalidated)", requests.Load(), revalidations.Load())
+		}
+
+		// responses are not shared between tokens
+		if _, _, err := do(transport, http.MethodGet, "/repos", "5678"); err != nil {
+			t.Fatal(err)
+		}
+
+		if revalidations.Load() != 2 {
+			t.Errorf("response of another token revalidated")
+		}
+	})
+
+	t.Run("Bypass", func(t *testing.T) {
+		revalidations.Store(0)
+		transport := newCachedTransport(http.DefaultTransport, t.TempDir(), defaultCacheSize)
+		transport.SetBypass(true)
+		for range 2 {
+			if _, _, err := do(transport, http.MethodGet, "/repos", "1234"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if entries, _ := os.ReadDir(transport.Directory); revalidations.Load() != 0 || len(entries) != 0 {
+			t.Errorf("cache not bypassed: got %d revalidations and %d cached responses", revalidations.Load(), len(entries))
+		}
+	})
+
+	t.Run("NotCacheable", func(t *testing.T) {
+		transport := newCachedTransport(http.DefaultTransport, t.TempDir(), defaultCacheSize)
+		for _, tt := range []struct{ method, path string }{
+			{http.MethodPatch, "/repos"},
+			{http.MethodGet, "/uncached"},
+			{http.MethodGet, "/rate_limit"},
+		} {
+			if _, _, err := do(transport, tt.method, tt.path, "1234"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if entries, _ := os.ReadDir(transport.Directory); len(entries) != 0 {
+			t.Errorf("unexpected cached responses: got %d, want 0", len(entries))
+		}
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		transport := newCachedTransport(http.DefaultTransport, t.TempDir(), 1)
+		for _, path := range []string{"/a", "/b", "/c"} {
+			if _, _, err := do(transport, http.MethodGet, path, "1234"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if entries, _ := filepath.Glob(filepath.Join(transport.Directory, "*"+cacheFileExtension)); len(entries) != 0 {
+			t.Errorf("cache not pruned: got %d cached responses, want 0", len(entries))
+		}
+
+		transport.MaxSize = defaultCacheSize
+		for _, path := range []string{"/a", "/b", "/c"} {
+			if _, _, err := do(transport, http.MethodGet, path, "1234"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		entries, size, err := transport.Clear()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entries != 3 || size == 0 {
+			t.Errorf("(*cachedTransport).Clear() failed: got %d entries of %d bytes, want 3", entries, size)
+		}
+
+		if _, err := os.Stat(transport.Directory); !os.IsNotExist(err) {
+			t.Errorf("cache directory not removed: %v", err)
+		}
+	})
+
+	t.Run("Estimate", func(t *testing.T) {
+		transport := newCachedTransport(http.DefaultTransport, t.TempDir(), 1<<10)
+		if _, _, err := do(transport, http.MethodGet, "/a", "1234"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the cache is measured only once its estimated size exceeds the limit
+		foreign := filepath.Join(transport.Directory, "foreign"+cacheFileExtension)
+		if err := os.WriteFile(foreign, make([]byte, 2<<10), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		past := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(foreign, past, past); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := do(transport, http.MethodGet, "/b", "1234"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(foreign); err != nil {
+			t.Errorf("cache pruned below its estimated limit: %v", err)
+		}
+
+		transport.MaxSize = transport.size
+		if _, _, err := do(transport, http.MethodGet, "/c", "1234"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(foreign); !os.IsNotExist(err) {
+			t.Errorf("least recently used response not evicted: %v", err)
+		}
+
+		// response "/a" is evicted as well, since the limit covers responses "/a" and "/b" only
+		if entries, _ := filepath.Glob(filepath.Join(transport.Directory, "*"+cacheFileExtension)); len(entries) != 2 {
+			t.Errorf("unexpected cached responses: got %d, want 2", len(entries))
+		}
+	})
+
+	t.Run("Shared", func(t *testing.T) {
+		transport := newCachedTransport(http.DefaultTransport, t.TempDir(), defaultCacheSize)
+		shared := transport.withTransport(http.DefaultTransport)
+		if _, _, err := do(shared, http.MethodGet, "/a", "1234"); err != nil {
+			t.Fatal(err)
+		}
+
+		if transport.size <= 0 {
+			t.Errorf("size of the cache not shared: got %d", transport.size)
+		}
+
+		transport.SetBypass(true)
+		req := httptest.NewRequest(http.MethodGet, server.URL+"/a", nil)
+		if shared.isCacheable(req) {
+			t.Errorf("bypass of the cache not shared")
+		}
+	})
+}
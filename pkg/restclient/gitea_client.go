@@ -0,0 +1,408 @@
+package restclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/cli/go-gh/v2/pkg/api"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
+// Number of items requested per page from the Gitea API.
+const giteaPageSize = 50
+
+// giteaUser represents a user of the Gitea API.
+type giteaUser struct {
+	ID        int    `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
+}
+
+// toResource converts the user into the resource of the GitHub API.
+func (u giteaUser) toResource() *resources.User {
+	return &resources.User{
+		ID:        u.ID,
+		Login:     u.Login,
+		Name:      u.FullName,
+		Email:     u.Email,
+		AvatarURL: u.AvatarURL,
+		HTMLURL:   u.HTMLURL,
+		URL:       u.HTMLURL,
+	}
+}
+
+// giteaOrganization represents an organization of the Gitea API.
+type giteaOrganization struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"` // Deprecated: Name should be used instead.
+	FullName string `json:"full_name"`
+}
+
+// toResource converts the organization into the resource of the GitHub API.
+func (o giteaOrganization) toResource() resources.Organization {
+	org := resources.Organization{ID: o.ID, Login: o.Name, Name: o.FullName}
+	if org.Login == "" {
+		org.Login = o.Username
+	}
+
+	return org
+}
+
+// GiteaClient is a client of the API of Gitea and Forgejo.
+// Endpoints, repositories and pull requests of the Gitea API are compatible with the ones of the GitHub API.
+type GiteaClient struct {
+	*http.Client
+	*configfile.Configuration
+	*util.Progressbar
+	baseURL *url.URL
+	token   string
+}
+
+// Close a pull request.
+func (c *GiteaClient) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	c.Describe("Closing pull request %d for Gitea repository: %s/%s...", number, owner, repo)
+	return c.DoWithContext(ctx, http.MethodPatch,
+		newRequestPath(pullEp.Format(map[string]any{"owner": owner, "repo": repo, "number": number})).String(),
+		strings.NewReader(`{"state":"closed"}`), nil)
+}
+
+// Send a request to the Gitea API and decode its response.
+// Responses with a status code other than 2xx are reported as api.HTTPError.
+func (c *GiteaClient) DoWithContext(ctx context.Context, method string, path string, body io.Reader, response any) error {
+	_, err := c.do(ctx, method, path, body, response)
+	return err
+}
+
+// do sends a request to the Gitea API, decodes its response and returns its headers.
+func (c *GiteaClient) do(ctx context.Context, method string, path string, body io.Reader, response any) (http.Header, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(ref).String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var payload struct {
+			Message string `json:"message"`
+		}
+
+		raw, _ := io.ReadAll(resp.Body)
+		_ = json.Unmarshal(raw, &payload)
+
+		return nil, &api.HTTPError{
+			Headers:    resp.Header,
+			Message:    payload.Message,
+			RequestURL: req.URL,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	if response == nil {
+		return resp.Header, nil
+	}
+
+	return resp.Header, json.NewDecoder(resp.Body).Decode(response)
+}
+
+// Get all repositories for given user and the organizations he belongs to.
+func (c *GiteaClient) GetAllUserRepos(ctx context.Context, include, exclude []string) ([]resources.Repository, error) {
+	return getAllUserRepos(ctx, c, c.Progressbar, include, exclude)
+}
+
+// Installations of GitHub Apps are not supported by Gitea.
+func (c *GiteaClient) GetInstallationRepos(context.Context) ([]resources.Repository, error) {
+	return nil, fmt.Errorf("installation repositories: %w", errors.ErrUnsupported)
+}
+
+// Get all pull requests for given organization and repository.
+// Pull requests are filtered by state on the server and by base and head (<owner>:<ref>) on the client.
+func (c *GiteaClient) GetOrgRepoPulls(ctx context.Context, name, repo string, filter map[string]string) ([]resources.PullRequest, error) {
+	c.Describe("Retrieving pull requests for Gitea repository: %s/%s...", name, repo)
+
+	pulls, err := getGiteaPaged[resources.PullRequest](ctx, c, pullsEp.Format(map[string]any{"owner": name, "repo": repo}), func(params *requestPath) {
+		if state := filter["state"]; state != "" {
+			params.Set("state", state)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headOwner, headRef, qualified := strings.Cut(filter["head"], ":")
+	if !qualified {
+		headOwner, headRef = "", headOwner
+	}
+
+	var out []resources.PullRequest
+	for _, pull := range pulls {
+		switch {
+		case
+			filter["base"] != "" && pull.Base.Ref != filter["base"],
+			headRef != "" && pull.Head.Ref != headRef,
+			headOwner != "" && !strings.EqualFold(pull.Head.Repo.Owner.Login, headOwner):
+
+			continue
+		}
+
+		pull.Repository = name + "/" + repo
+		out = append(out, pull)
+	}
+
+	return out, nil
+}
+
+// Get all repositories for given organization.
+func (c *GiteaClient) GetOrgRepos(ctx context.Context, name string) ([]resources.Repository, error) {
+	c.Describe("Retrieving repositories for Gitea organization: %s...", name)
+	return getGiteaPaged[resources.Repository](ctx, c, orgReposEp.Format(map[string]any{"owner": name}))
+}
+
+// Get Gitea user.
+func (c *GiteaClient) GetUser(ctx context.Context) (*resources.User, error) {
+	var user giteaUser
+	if err := c.DoWithContext(ctx, http.MethodGet, newRequestPath(userEp).String(), nil, &user); err != nil {
+		return nil, err
+	}
+
+	return user.toResource(), nil
+}
+
+// Get Gitea user by login.
+func (c *GiteaClient) GetUserByLogin(ctx context.Context, login string) (*resources.User, error) {
+	var user giteaUser
+	if err := c.DoWithContext(ctx, http.MethodGet, newRequestPath(usersEp.Format(map[string]any{"username": login})).String(), nil, &user); err != nil {
+		return nil, err
+	}
+
+	return user.toResource(), nil
+}
+
+// Get all organizations for given user.
+func (c *GiteaClient) GetUserOrgs(ctx context.Context) ([]resources.Organization, error) {
+	c.Describe("Retrieving Gitea organizations for current user...")
+	orgs, err := getGiteaPaged[giteaOrganization](ctx, c, userOrgsEp)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]resources.Organization, 0, len(orgs))
+	for _, org := range orgs {
+		out = append(out, org.toResource())
+	}
+
+	return out, nil
+}
+
+// Get all repositories for given user.
+func (c *GiteaClient) GetUserRepos(ctx context.Context) ([]resources.Repository, error) {
+	c.Describe("Retrieving repositories for current user...")
+	return getGiteaPaged[resources.Repository](ctx, c, userReposEp)
+}
+
+// Reopen a pull request.
+func (c *GiteaClient) ReopenPullRequest(ctx context.Context, owner, repo string, number int) error {
+	c.Describe("Reopening pull request %d for Gitea repository: %s/%s...", number, owner, repo)
+	return c.DoWithContext(ctx, http.MethodPatch,
+		newRequestPath(pullEp.Format(map[string]any{"owner": owner, "repo": repo, "number": number})).String(),
+		strings.NewReader(`{"state":"open"}`), nil)
+}
+
+// Search for pull requests in a repository.
+// Since Gitea does not support the search syntax of GitHub, the pull requests of the repository are matched against the query
+// on the client (see matchGiteaPullRequest).
+func (c *GiteaClient) SearchOrgRepoPulls(ctx context.Context, name, repo string, filter string) ([]resources.PullRequest, error) {
+	terms := strings.Fields(filter)
+
+	state := "all"
+	for _, term := range terms {
+		switch qualifier, value, _ := strings.Cut(term, ":"); {
+		case qualifier == "state", qualifier == "is" && (value == "open" || value == "closed"):
+			state = value
+		}
+	}
+
+	pulls, err := c.GetOrgRepoPulls(ctx, name, repo, map[string]string{"state": state})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []resources.PullRequest
+	for _, pull := range pulls {
+		match, err := matchGiteaPullRequest(pull, terms)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			out = append(out, pull)
+		}
+	}
+
+	return out, nil
+}
+
+// getGiteaPaged retrieves all pages of given endpoint.
+// Pages are requested until the total count (X-Total-Count) is reached or a page is not full.
+func getGiteaPaged[T any](ctx context.Context, c *GiteaClient, ep apiEndpoint, options ...func(*requestPath)) ([]T, error) {
+	var out []T
+	for page := 1; ; page++ {
+		path := newRequestPath(ep).
+			Add("limit", fmt.Sprintf("%d", giteaPageSize)).
+			Add("page", fmt.Sprintf("%d", page))
+		for _, option := range options {
+			option(path)
+		}
+
+		var paged []T
+		header, err := c.do(ctx, http.MethodGet, path.String(), nil, &paged)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, paged...)
+		total, err := strconv.Atoi(header.Get("X-Total-Count"))
+		if len(paged) < giteaPageSize || (err == nil && len(out) >= total) {
+			return out, nil
+		}
+	}
+}
+
+// matchGiteaPullRequest matches a pull request against the terms of a search query in the syntax of GitHub.
+// Supported are the qualifiers is:, state:, base:, head:, author:, assignee:, label: and closed: (with >= or <=),
+// other terms are matched against the title. Unsupported qualifiers are reported.
+func matchGiteaPullRequest(pull resources.PullRequest, terms []string) (bool, error) {
+	for _, term := range terms {
+		qualifier, value, qualified := strings.Cut(term, ":")
+		if !qualified {
+			if !strings.Contains(strings.ToLower(pull.Title), strings.ToLower(term)) {
+				return false, nil
+			}
+
+			continue
+		}
+
+		var match bool
+		switch qualifier {
+
+		case "is":
+			match = value == "pr" || value == pull.State || (value == "merged" && !pull.MergedAt.IsZero())
+
+		case "in":
+			match = value == "title"
+
+		case "state":
+			match = value == "all" || value == pull.State
+
+		case "base":
+			match = pull.Base.Ref == value
+
+		case "head":
+			owner, ref, found := strings.Cut(value, ":")
+			if !found {
+				owner, ref = "", owner
+			}
+
+			match = pull.Head.Ref == ref && (owner == "" || strings.EqualFold(pull.Head.Repo.Owner.Login, owner))
+
+		case "author":
+			match = strings.EqualFold(pull.User.Login, value)
+
+		case "assignee":
+			match = strings.EqualFold(pull.Assignee.Login, value)
+			for _, assignee := range pull.Assignees {
+				match = match || strings.EqualFold(assignee.Login, value)
+			}
+
+		case "label":
+			for _, label := range pull.Labels {
+				match = match || strings.EqualFold(label.Name, value)
+			}
+
+		case "closed":
+			operator, date := value[:min(2, len(value))], value[min(2, len(value)):]
+			timestamp, err := time.Parse(time.RFC3339, date)
+			if err != nil || (operator != ">=" && operator != "<=") {
+				return false, fmt.Errorf("unsupported search term: %q", term)
+			}
+
+			match = !pull.ClosedAt.IsZero() && (operator == ">=" && !pull.ClosedAt.Before(timestamp) || operator == "<=" && !pull.ClosedAt.After(timestamp))
+
+		default:
+			return false, fmt.Errorf("unsupported search qualifier: %q", qualifier)
+
+		}
+
+		if !match {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Create new Gitea API client.
+// If no token is provided, it is retrieved from the token sources configured for the first profile of the host.
+// Requests are sent through the same transports as requests to the GitHub API (caching, network settings of the host).
+func NewGiteaClient(conf *configfile.Configuration, options ClientOptions, retry bool) (*GiteaClient, error) {
+	if options.Host == "" {
+		return nil, errors.New("gitea client: host is required")
+	}
+
+	baseURL, err := url.Parse("https://" + options.Host + "/api/v1/")
+	if err != nil {
+		return nil, err
+	}
+
+	bar := util.NewProgressbar(-1)
+	if options, err = prepareClientOptions(conf, options, retry, bar); err != nil {
+		return nil, err
+	}
+
+	loggerEntry.Debugf("Creating Gitea client for host: %s", options.Host)
+	return &GiteaClient{
+		Client:        &http.Client{Transport: options.Transport, Timeout: options.Timeout},
+		Configuration: conf,
+		Progressbar:   bar,
+		baseURL:       baseURL,
+		token:         options.AuthToken,
+	}, nil
+}
+
+// Ensure the clients implement the provider interface.
+var (
+	_ Provider = (*RESTClient)(nil)
+	_ Provider = (*GiteaClient)(nil)
+)
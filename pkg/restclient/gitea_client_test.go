@@ -0,0 +1,240 @@
+package restclient
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	api "github.com/cli/go-gh/v2/pkg/api"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+func setupTestGiteaClient(tb testing.TB, token string, handler http.HandlerFunc) *GiteaClient {
+	tb.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	tb.Cleanup(server.Close)
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		tb.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	defaultCachedTransport.Directory = tb.TempDir()
+	provider, err := NewProvider(
+		&configfile.Configuration{Concurrency: 1, Timeout: time.Minute},
+		configfile.ProviderGitea,
+		ClientOptions{
+			AuthToken: token,
+			Host:      parsed.Host,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+		false,
+	)
+	if err != nil {
+		tb.Fatalf("Failed to create Gitea client: %v", err)
+	}
+
+	client, ok := provider.(*GiteaClient)
+	if !ok {
+		tb.Fatalf("Unexpected provider: %T", provider)
+	}
+
+	return client
+}
+
+func TestGiteaClient(t *testing.T) {
+	pulls := []map[string]any{
+		{
+			"number": 1, "title": "Add feature", "state": "open",
+			"user":   map[string]any{"login": "octocat"},
+			"labels": []map[string]any{{"name": "bug"}},
+			"base":   map[string]any{"ref": "main"},
+			"head":   map[string]any{"ref": "feature", "repo": map[string]any{"owner": map[string]any{"login": "octocat"}}},
+		},
+		{
+			"number": 2, "title": "Fix typo", "state": "closed", "closed_at": "2024-01-02T00:00:00Z",
+			"user":      map[string]any{"login": "hubot"},
+			"assignees": []map[string]any{{"login": "octocat"}},
+			"base":      map[string]any{"ref": "develop"},
+			"head":      map[string]any{"ref": "typo", "repo": map[string]any{"owner": map[string]any{"login": "hubot"}}},
+		},
+	}
+
+	var patched []string
+	client := setupTestGiteaClient(t, "1234", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token 1234" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"token is required"}`))
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		paginate := func(items []map[string]any) []map[string]any {
+			w.Header().Set("X-Total-Count", fmt.Sprint(len(items)))
+			start, end := min((page-1)*limit, len(items)), min(page*limit, len(items))
+			return items[start:end]
+		}
+
+		var response any
+		switch r.Method + " " + r.URL.Path {
+
+		case "GET /api/v1/user":
+			response = map[string]any{"id": 1, "login": "octocat", "full_name": "The Octocat", "email": "octocat@example.com"}
+
+		case "GET /api/v1/user/repos":
+			var repos []map[string]any
+			for i := range giteaPageSize + 10 {
+				repos = append(repos, map[string]any{"full_name": fmt.Sprintf("octocat/repo-%d", i), "permissions": map[string]any{"pull": true, "push": true}})
+			}
+			response = paginate(repos)
+
+		case "GET /api/v1/user/orgs":
+			response = paginate([]map[string]any{{"id": 2, "name": "gitea-org"}})
+
+		case "GET /api/v1/orgs/gitea-org/repos":
+			response = paginate([]map[string]any{{"full_name": "gitea-org/repo"}})
+
+		case "GET /api/v1/repos/octocat/repo-0/pulls":
+			var filtered []map[string]any
+			for _, pull := range pulls {
+				if state := r.URL.Query().Get("state"); state == "" || state == "all" || state == pull["state"] {
+					filtered = append(filtered, pull)
+				}
+			}
+			response = paginate(filtered)
+
+		case "PATCH /api/v1/repos/octocat/repo-0/pulls/1":
+			body, _ := io.ReadAll(r.Body)
+			patched = append(patched, string(body))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+			return
+
+		}
+
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	t.Run("GetUser", func(t *testing.T) {
+		user, err := client.GetUser(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if user.Login != "octocat" || user.Name != "The Octocat" || user.Email != "octocat@example.com" {
+			t.Errorf("(*GiteaClient).GetUser() failed: got %+v", user)
+		}
+	})
+
+	t.Run("GetAllUserRepos", func(t *testing.T) {
+		repos, err := client.GetAllUserRepos(context.TODO(), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(repos) != giteaPageSize+11 || repos[len(repos)-1].FullName != "gitea-org/repo" || !repos[0].Permissions.Push {
+			t.Errorf("(*GiteaClient).GetAllUserRepos() failed: got %d repositories", len(repos))
+		}
+	})
+
+	t.Run("GetOrgRepoPulls", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			filter map[string]string
+			want   []int
+		}{
+			{"test#1", map[string]string{"state": "open"}, []int{1}},
+			{"test#2", map[string]string{"state": "all", "base": "develop"}, []int{2}},
+			{"test#3", map[string]string{"state": "all", "head": "octocat:feature"}, []int{1}},
+			{"test#4", map[string]string{"state": "all", "head": "octocat:typo"}, nil},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := client.GetOrgRepoPulls(context.TODO(), "octocat", "repo-0", tt.filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var numbers []int
+				for _, pull := range got {
+					numbers = append(numbers, pull.Number)
+					if pull.Repository != "octocat/repo-0" {
+						t.Errorf("Unexpected repository: %q", pull.Repository)
+					}
+				}
+
+				if fmt.Sprint(numbers) != fmt.Sprint(tt.want) {
+					t.Errorf("(*GiteaClient).GetOrgRepoPulls(%v) failed: got %v, want %v", tt.filter, numbers, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("SearchOrgRepoPulls", func(t *testing.T) {
+		for _, tt := range []struct {
+			name    string
+			query   string
+			want    []int
+			wantErr bool
+		}{
+			{"test#1", "state:open label:bug", []int{1}, false},
+			{"test#2", "state:all assignee:octocat", []int{2}, false},
+			{"test#3", "closed:>=2024-01-01T00:00:00Z typo in:title", []int{2}, false},
+			{"test#4", "author:octocat feature", []int{1}, false},
+			{"test#5", "review:approved", nil, true},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := client.SearchOrgRepoPulls(context.TODO(), "octocat", "repo-0", tt.query)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("(*GiteaClient).SearchOrgRepoPulls(%q) failed: got error %v, want error: %t", tt.query, err, tt.wantErr)
+				}
+
+				var numbers []int
+				for _, pull := range got {
+					numbers = append(numbers, pull.Number)
+				}
+
+				if fmt.Sprint(numbers) != fmt.Sprint(tt.want) {
+					t.Errorf("(*GiteaClient).SearchOrgRepoPulls(%q) failed: got %v, want %v", tt.query, numbers, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("ClosePullRequest", func(t *testing.T) {
+		if err := client.ClosePullRequest(context.TODO(), "octocat", "repo-0", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.ReopenPullRequest(context.TODO(), "octocat", "repo-0", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(patched) != `[{"state":"closed"} {"state":"open"}]` {
+			t.Errorf("Unexpected requests: %v", patched)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		var httpErr *api.HTTPError
+		if _, err := client.GetOrgRepos(context.TODO(), "missing"); !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+			t.Errorf("(*GiteaClient).GetOrgRepos() failed: got error %v, want HTTP 404", err)
+		}
+
+		if _, err := client.GetInstallationRepos(context.TODO()); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("(*GiteaClient).GetInstallationRepos() failed: got error %v, want %v", err, errors.ErrUnsupported)
+		}
+	})
+}
@@ -0,0 +1,441 @@
+package restclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/cli/go-gh/v2/pkg/api"
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
+// Maximum number of repositories queried in a single request.
+const graphQLBatchSize = 25
+
+// Fields of pull requests retrieved through GraphQL API.
+const graphQLPullRequestFragment = `fragment pr on PullRequest {
+	number
+	title
+	state
+	url
+	isDraft
+	createdAt
+	updatedAt
+	closedAt
+	mergedAt
+	author { login }
+	baseRefName
+	headRefName
+	headRepositoryOwner { login }
+	repository { nameWithOwner }
+	assignees(first: 20) { nodes { login } }
+	labels(first: 20) { nodes { name } }
+	reviewDecision
+	commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+}`
+
+// GraphQL API client.
+type GraphQLClient struct {
+	*api.GraphQLClient
+	*configfile.Configuration
+	*util.Progressbar
+}
+
+// graphQLLogin is an actor of the GraphQL API.
+type graphQLLogin struct {
+	Login string `json:"login"`
+}
+
+// graphQLPullRequest is a pull request retrieved through the GraphQL API.
+type graphQLPullRequest struct {
+	Number              int           `json:"number"`
+	Title               string        `json:"title"`
+	State               string        `json:"state"`
+	URL                 string        `json:"url"`
+	IsDraft             bool          `json:"isDraft"`
+	CreatedAt           time.Time     `json:"createdAt"`
+	UpdatedAt           time.Time     `json:"updatedAt"`
+	ClosedAt            time.Time     `json:"closedAt"`
+	MergedAt            time.Time     `json:"mergedAt"`
+	Author              *graphQLLogin `json:"author"`
+	BaseRefName         string        `json:"baseRefName"`
+	HeadRefName         string        `json:"headRefName"`
+	HeadRepositoryOwner *graphQLLogin `json:"headRepositoryOwner"`
+	Repository          struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+	Assignees struct {
+		Nodes []graphQLLogin `json:"nodes"`
+	} `json:"assignees"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	ReviewDecision string `json:"reviewDecision"`
+	Commits        struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					State string `json:"state"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+// graphQLPullRequestConnection is a page of pull requests.
+type graphQLPullRequestConnection struct {
+	Nodes    []*graphQLPullRequest `json:"nodes"`
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+}
+
+// graphQLPullRequestResult is the result of an aliased query, i.e. either a repository or a search.
+type graphQLPullRequestResult struct {
+	graphQLPullRequestConnection
+	IssueCount   int                           `json:"issueCount"`
+	PullRequests *graphQLPullRequestConnection `json:"pullRequests"`
+}
+
+// page returns the pull requests of the result.
+func (r *graphQLPullRequestResult) page() *graphQLPullRequestConnection {
+	if r.PullRequests != nil {
+		return r.PullRequests
+	}
+
+	return &r.graphQLPullRequestConnection
+}
+
+// graphQLPullRequestQuery is a paginated query of pull requests of a single repository or a search across repositories.
+type graphQLPullRequestQuery struct {
+	repository string
+	search     *searchQuery
+	variables  map[string]any
+	cursor     string
+}
+
+// repositories returns the repositories matched by the query.
+func (q *graphQLPullRequestQuery) repositories() []string {
+	if q.search != nil {
+		return q.search.Repositories
+	}
+
+	return []string{q.repository}
+}
+
+// mapResult maps the repository of a pull request onto the repositories matched by the query.
+func (q *graphQLPullRequestQuery) mapResult(pr *graphQLPullRequest) (string, bool) {
+	if q.search != nil {
+		return q.search.mapSearchResult(pr.Repository.NameWithOwner)
+	}
+
+	return q.repository, true
+}
+
+// Convert pull request into the representation of the REST API.
+func (pr *graphQLPullRequest) toResource(repository string) resources.PullRequest {
+	out := resources.PullRequest{
+		Repository:     repository,
+		HTMLURL:        pr.URL,
+		Number:         pr.Number,
+		State:          strings.ToLower(pr.State),
+		Title:          pr.Title,
+		CreatedAt:      pr.CreatedAt,
+		UpdatedAt:      pr.UpdatedAt,
+		ClosedAt:       pr.ClosedAt,
+		MergedAt:       pr.MergedAt,
+		Draft:          pr.IsDraft,
+		Base:           resources.Branch{Ref: pr.BaseRefName},
+		Head:           resources.Branch{Ref: pr.HeadRefName},
+		ReviewDecision: pr.ReviewDecision,
+	}
+
+	// merged pull requests are closed in terms of the REST API
+	if out.State == "merged" {
+		out.State = "closed"
+	}
+
+	if pr.Author != nil {
+		out.User.Login = pr.Author.Login
+	}
+
+	if pr.HeadRepositoryOwner != nil {
+		out.Head.Label = pr.HeadRepositoryOwner.Login + ":" + pr.HeadRefName
+		out.Head.User.Login = pr.HeadRepositoryOwner.Login
+	}
+
+	for i, assignee := range pr.Assignees.Nodes {
+		if i == 0 {
+			out.Assignee.Login = assignee.Login
+		}
+
+		out.Assignees = append(out.Assignees, resources.User{Login: assignee.Login})
+	}
+
+	for _, label := range pr.Labels.Nodes {
+		out.Labels = append(out.Labels, resources.Label{Name: label.Name})
+	}
+
+	for _, commit := range pr.Commits.Nodes {
+		if rollup := commit.Commit.StatusCheckRollup; rollup != nil {
+			out.CheckStatus = rollup.State
+		}
+	}
+
+	return out
+}
+
+// Get all pull requests for given repositories (<owner>/<name>) through batched queries.
+// Supported filters are "state" ("open", "closed", "all"), "base" and "head" ("<user>:<ref-name>").
+// Pull requests are mapped to the repositories. Errors concerning single repositories (e.g. not found) are mapped separately,
+// whereas an error is returned, if a query failed as a whole.
+func (c *GraphQLClient) GetReposPulls(ctx context.Context, repos []string, filter map[string]string) (map[string][]resources.PullRequest, map[string]error, error) {
+	c.Describe("Retrieving pull requests for %d GitHub repositories...", len(repos))
+
+	var declarations, arguments []string
+	shared := make(map[string]any)
+	switch filter["state"] {
+
+	case "open":
+		shared["states"] = []string{"OPEN"}
+
+	case "closed":
+		shared["states"] = []string{"CLOSED", "MERGED"}
+
+	}
+
+	if _, ok := shared["states"]; ok {
+		declarations, arguments = append(declarations, "$states: [PullRequestState!]"), append(arguments, "states: $states")
+	}
+
+	if base := filter["base"]; base != "" {
+		shared["baseRefName"] = base
+		declarations, arguments = append(declarations, "$baseRefName: String"), append(arguments, "baseRefName: $baseRefName")
+	}
+
+	// the head filter of the REST API is qualified by the owner of the head repository
+	headOwner, headRef, qualified := strings.Cut(filter["head"], ":")
+	if !qualified {
+		headOwner, headRef = "", filter["head"]
+	}
+
+	if headRef != "" {
+		shared["headRefName"] = headRef
+		declarations, arguments = append(declarations, "$headRefName: String"), append(arguments, "headRefName: $headRefName")
+	}
+
+	queries := make([]*graphQLPullRequestQuery, 0, len(repos))
+	for _, repo := range repos {
+		owner, name, _ := strings.Cut(repo, "/")
+		queries = append(queries, &graphQLPullRequestQuery{repository: repo, variables: map[string]any{"owner": owner, "name": name}})
+	}
+
+	pulls, errs, err := c.paginate(ctx, queries, shared, func(alias string) (string, []string) {
+		return fmt.Sprintf(`%[1]s: repository(owner: $%[1]sowner, name: $%[1]sname) {
+	pullRequests(first: 100, after: $%[1]scursor, orderBy: {field: CREATED_AT, direction: DESC}%[2]s) {
+		nodes { ...pr }
+		pageInfo { hasNextPage endCursor }
+	}
+}`, alias, strings.Join(append([]string{""}, arguments...), ", ")), []string{
+			fmt.Sprintf("$%sowner: String!", alias),
+			fmt.Sprintf("$%sname: String!", alias),
+			fmt.Sprintf("$%scursor: String", alias),
+		}
+	}, declarations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if headOwner != "" {
+		for repo, list := range pulls {
+			var kept []resources.PullRequest
+			for _, pull := range list {
+				if strings.EqualFold(pull.Head.User.Login, headOwner) {
+					kept = append(kept, pull)
+				}
+			}
+			pulls[repo] = kept
+		}
+	}
+
+	return pulls, errs, nil
+}
+
+// Search for pull requests in given repositories (<owner>/<name>) through batched queries.
+// Repositories are grouped by owner into combined search queries (see buildSearchQueries), the owner is qualified with user:,
+// if it is given user. Pull requests are mapped back onto the repositories. Errors concerning single repositories are mapped separately,
+// whereas an error is returned, if a query failed as a whole.
+func (c *GraphQLClient) SearchReposPulls(ctx context.Context, user string, repos []string, filter string) (map[string][]resources.PullRequest, map[string]error, error) {
+	searches := buildSearchQueries(user, repos, filter)
+	c.Describe("Searching pull requests for %d GitHub repositories using %d queries...", len(repos), len(searches))
+
+	queries := make([]*graphQLPullRequestQuery, 0, len(searches))
+	for _, search := range searches {
+		queries = append(queries, &graphQLPullRequestQuery{search: &search, variables: map[string]any{"query": search.Query}})
+	}
+
+	return c.paginate(ctx, queries, nil, func(alias string) (string, []string) {
+		return fmt.Sprintf(`%[1]s: search(query: $%[1]squery, type: ISSUE, first: 100, after: $%[1]scursor) {
+	issueCount
+	nodes { ...pr }
+	pageInfo { hasNextPage endCursor }
+}`, alias), []string{
+			fmt.Sprintf("$%squery: String!", alias),
+			fmt.Sprintf("$%scursor: String", alias),
+		}
+	}, nil)
+}
+
+// paginate executes given queries in batches until all pages have been retrieved.
+// Each query is represented by an alias, for which the selection and the declarations of its variables are rendered.
+// The variables of each query are prefixed with its alias, whereas shared variables are declared once.
+func (c *GraphQLClient) paginate(
+	ctx context.Context,
+	queries []*graphQLPullRequestQuery,
+	shared map[string]any,
+	render func(alias string) (string, []string),
+	sharedDeclarations []string,
+) (map[string][]resources.PullRequest, map[string]error, error) {
+	pulls := make(map[string][]resources.PullRequest)
+	errs := make(map[string]error)
+
+	for pending := queries; len(pending) > 0; {
+		batch := pending[:min(graphQLBatchSize, len(pending))]
+		pending = pending[len(batch):]
+
+		declarations := append([]string{}, sharedDeclarations...)
+		variables := make(map[string]any, len(shared))
+		for k, v := range shared {
+			variables[k] = v
+		}
+
+		var selections []string
+		aliases := make(map[string]*graphQLPullRequestQuery, len(batch))
+		for i, query := range batch {
+			alias := fmt.Sprintf("r%d", i)
+			aliases[alias] = query
+
+			selection, declared := render(alias)
+			selections, declarations = append(selections, selection), append(declarations, declared...)
+			for k, v := range query.variables {
+				variables[alias+k] = v
+			}
+
+			if query.cursor != "" {
+				variables[alias+"cursor"] = query.cursor
+			} else {
+				variables[alias+"cursor"] = nil
+			}
+		}
+
+		document := fmt.Sprintf("query(%s) {\n%s\n}\n%s", strings.Join(declarations, ", "), strings.Join(selections, "\n"), graphQLPullRequestFragment)
+
+		var data map[string]*graphQLPullRequestResult
+
+		err := c.DoWithContext(ctx, document, variables, &data)
+
+		// errors of single aliases (e.g. repository not found) are returned along with the data of the other aliases
+		var gqlErr *api.GraphQLError
+		switch {
+
+		case errors.As(err, &gqlErr) && data != nil:
+			for _, item := range gqlErr.Errors {
+				if len(item.Path) == 0 {
+					continue
+				}
+
+				alias, ok := item.Path[0].(string)
+				if !ok || aliases[alias] == nil {
+					continue
+				}
+
+				// owner cannot be qualified as a whole, retry qualifying repositories individually
+				if query := aliases[alias]; query.search != nil && query.search.Owner != "" && query.cursor == "" {
+					for _, search := range query.search.split() {
+						pending = append(pending, &graphQLPullRequestQuery{search: &search, variables: map[string]any{"query": search.Query}})
+					}
+
+					delete(aliases, alias)
+					continue
+				}
+
+				for _, repo := range aliases[alias].repositories() {
+					errs[repo] = errors.New(item.Message)
+				}
+			}
+
+		case err != nil:
+			return nil, nil, err
+
+		}
+
+		for alias, query := range aliases {
+			result := data[alias]
+			if result == nil || errs[query.repositories()[0]] != nil {
+				continue
+			}
+
+			// search results are capped, retry searching fewer repositories at once
+			if search := query.search; search != nil && query.cursor == "" && search.isCapped(result.IssueCount, false) {
+				if split := search.split(); len(split) > 0 {
+					loggerEntry.Debugf("Search results of %q are capped (total: %d), splitting query", search.Query, result.IssueCount)
+					for _, search := range split {
+						pending = append(pending, &graphQLPullRequestQuery{search: &search, variables: map[string]any{"query": search.Query}})
+					}
+
+					continue
+				}
+
+				loggerEntry.Warnf("Search results of %q are capped (total: %d), some pull requests may be missing", search.Query, result.IssueCount)
+			}
+
+			page := result.page()
+			for _, node := range page.Nodes {
+				if node == nil {
+					continue
+				}
+
+				// search results of other repositories of the owner are discarded
+				if repo, ok := query.mapResult(node); ok {
+					pulls[repo] = append(pulls[repo], node.toResource(repo))
+				}
+			}
+
+			if page.PageInfo.HasNextPage {
+				query.cursor = page.PageInfo.EndCursor
+				pending = append(pending, query)
+			}
+		}
+	}
+
+	return pulls, errs, nil
+}
+
+// Create new GraphQL API client.
+// If no token is provided, it is retrieved from the token sources configured for the first profile of the host.
+func NewGraphQLClient(conf *configfile.Configuration, options ClientOptions, retry bool) (*GraphQLClient, error) {
+	bar := util.NewProgressbar(-1)
+	options, err := prepareClientOptions(conf, options, retry, bar)
+	if err != nil {
+		return nil, err
+	}
+
+	loggerEntry.Debugf("Creating GraphQL client with options: %+v", options)
+	client, err := api.NewGraphQLClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GraphQLClient{
+		GraphQLClient: client,
+		Configuration: conf,
+		Progressbar:   bar,
+	}, nil
+}
@@ -0,0 +1,276 @@
+package restclient
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sarumaj/gh-gr/v2/pkg/configfile"
+)
+
+func setupTestGraphQLClient(tb testing.TB, handler http.HandlerFunc) *GraphQLClient {
+	tb.Helper()
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{}
+	server.StartTLS()
+	tb.Cleanup(server.Close)
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		tb.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	client, err := NewGraphQLClient(
+		&configfile.Configuration{Concurrency: 16, Timeout: time.Hour},
+		ClientOptions{
+			AuthToken: "1234",
+			Host:      parsed.Host,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+		true,
+	)
+	if err != nil {
+		tb.Fatalf("Failed to create GraphQL client: %v", err)
+	}
+
+	return client
+}
+
+func TestGraphQLClient(t *testing.T) {
+	aliasRegex := regexp.MustCompile(`(r\d+): (repository|search)\(`)
+
+	var requests atomic.Int64
+	client := setupTestGraphQLClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		var payload struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		node := func(repository string) map[string]any {
+			return map[string]any{
+				"number":              1,
+				"title":               repository,
+				"state":               "MERGED",
+				"url":                 "https://example.com/" + repository,
+				"author":              map[string]any{"login": "octocat"},
+				"headRefName":         "feature",
+				"headRepositoryOwner": map[string]any{"login": "octocat"},
+				"repository":          map[string]any{"nameWithOwner": repository},
+				"assignees":           map[string]any{"nodes": []map[string]any{{"login": "hubot"}}},
+				"labels":              map[string]any{"nodes": []map[string]any{{"name": "bug"}}},
+				"reviewDecision":      "APPROVED",
+				"commits":             map[string]any{"nodes": []map[string]any{{"commit": map[string]any{"statusCheckRollup": map[string]any{"state": "FAILURE"}}}}},
+			}
+		}
+
+		data := make(map[string]any)
+		var errs []map[string]any
+		for _, match := range aliasRegex.FindAllStringSubmatch(payload.Query, -1) {
+			alias, kind := match[1], match[2]
+
+			switch query := fmt.Sprint(payload.Variables[alias+"query"]); {
+
+			case payload.Variables[alias+"owner"] == "missing", strings.Contains(query, "org:"):
+				data[alias] = nil
+				errs = append(errs, map[string]any{"type": "NOT_FOUND", "path": []string{alias}, "message": "Could not resolve"})
+
+			case kind == "search" && strings.Contains(query, "user:hubot"):
+				// search results of all repositories of the owner are capped
+				data[alias] = map[string]any{"issueCount": 1500, "nodes": []map[string]any{}, "pageInfo": map[string]any{"hasNextPage": true, "endCursor": "next"}}
+
+			case kind == "search":
+				// search results of all repositories of the owner (in upper case) and of individually qualified repositories
+				var nodes []map[string]any
+				for _, field := range strings.Fields(query) {
+					switch qualifier, value, _ := strings.Cut(field, ":"); qualifier {
+
+					case "repo":
+						nodes = append(nodes, node(value))
+
+					case "user":
+						for i := range graphQLBatchSize + 5 {
+							nodes = append(nodes, node(fmt.Sprintf("%s/REPO-%d", value, i)))
+						}
+						nodes = append(nodes, node(value+"/unconfigured"))
+
+					}
+				}
+
+				data[alias] = map[string]any{"nodes": nodes, "pageInfo": map[string]any{"hasNextPage": false}}
+
+			default:
+				// every repository has two pages with a single pull request each
+				data[alias] = map[string]any{"pullRequests": map[string]any{
+					"nodes": []map[string]any{node(fmt.Sprint(payload.Variables[alias+"owner"], "/", payload.Variables[alias+"name"]))},
+					"pageInfo": map[string]any{
+						"hasNextPage": payload.Variables[alias+"cursor"] == nil,
+						"endCursor":   "next",
+					},
+				}}
+
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data, "errors": errs})
+	})
+
+	var repos []string
+	for i := range graphQLBatchSize + 5 {
+		repos = append(repos, fmt.Sprintf("octocat/repo-%d", i))
+	}
+
+	t.Run("GetReposPulls", func(t *testing.T) {
+		requests.Store(0)
+		pulls, errs, err := client.GetReposPulls(context.TODO(), append(repos, "missing/repo"), map[string]string{"state": "closed"})
+		if err != nil {
+			t.Fatalf("Failed to get repos pulls: %v", err)
+		}
+
+		// 31 first pages and 30 second pages are queried in batches of 25
+		if got := requests.Load(); got != 3 {
+			t.Errorf("Unexpected number of requests: got %d, want 3", got)
+		}
+
+		if len(errs) != 1 || errs["missing/repo"] == nil {
+			t.Errorf("Unexpected errors: %v", errs)
+		}
+
+		for _, repo := range repos {
+			if len(pulls[repo]) != 2 {
+				t.Fatalf("Unexpected pull requests of %s: got %d, want 2", repo, len(pulls[repo]))
+			}
+
+			pull := pulls[repo][0]
+			if pull.Repository != repo || pull.State != "closed" || pull.User.Login != "octocat" || pull.Assignee.Login != "hubot" ||
+				len(pull.Labels) != 1 || pull.ReviewDecision != "APPROVED" || pull.CheckStatus != "FAILURE" {
+				t.Errorf("Unexpected pull request: %+v", pull)
+			}
+		}
+	})
+
+	t.Run("GetReposPullsByHead", func(t *testing.T) {
+		pulls, _, err := client.GetReposPulls(context.TODO(), repos[:1], map[string]string{"head": "hubot:feature"})
+		if err != nil {
+			t.Fatalf("Failed to get repos pulls: %v", err)
+		}
+
+		if len(pulls[repos[0]]) != 0 {
+			t.Errorf("Pull requests of other head owners not filtered: %v", pulls[repos[0]])
+		}
+	})
+
+	t.Run("SearchReposPulls", func(t *testing.T) {
+		// repositories of other owners cannot be qualified as a whole and are retried individually
+		all := slices.Clone(repos)
+		for i := range graphQLBatchSize + 5 {
+			all = append(all, fmt.Sprintf("someorg/repo-%d", i))
+		}
+
+		requests.Store(0)
+		pulls, errs, err := client.SearchReposPulls(context.TODO(), "octocat", all, "state:open")
+		if err != nil {
+			t.Fatalf("Failed to search repos pulls: %v", err)
+		}
+
+		if len(errs) != 0 {
+			t.Errorf("Unexpected errors: %v", errs)
+		}
+
+		if len(pulls) != len(all) {
+			t.Errorf("Unexpected repositories: got %d, want %d", len(pulls), len(all))
+		}
+
+		for _, repo := range all {
+			if len(pulls[repo]) != 1 || pulls[repo][0].Repository != repo {
+				t.Errorf("Unexpected pull requests of %s: %+v", repo, pulls[repo])
+			}
+		}
+	})
+
+	t.Run("SearchReposPullsCapped", func(t *testing.T) {
+		// results of the owner are capped and retried with repositories qualified individually
+		var all []string
+		for i := range 20 {
+			all = append(all, fmt.Sprintf("hubot/repository-%d", i))
+		}
+
+		pulls, errs, err := client.SearchReposPulls(context.TODO(), "hubot", all, "")
+		if err != nil {
+			t.Fatalf("Failed to search repos pulls: %v", err)
+		}
+
+		if len(errs) != 0 {
+			t.Errorf("Unexpected errors: %v", errs)
+		}
+
+		for _, repo := range all {
+			if len(pulls[repo]) != 1 || pulls[repo][0].Repository != repo {
+				t.Errorf("Unexpected pull requests of %s: %+v", repo, pulls[repo])
+			}
+		}
+	})
+}
+
+func TestGraphQLClientHostSettings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"r0": {"pullRequests": {"nodes": [], "pageInfo": {"hasNextPage": false}}}}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	// the certificate of the server is trusted through the CA bundle of the host only
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		hosts   configfile.Hosts
+		wantErr bool
+	}{
+		{"test#1", nil, true},
+		{"test#2", configfile.Hosts{{Host: parsed.Hostname(), CABundle: caBundle}}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewGraphQLClient(
+				&configfile.Configuration{Concurrency: 1, Timeout: time.Minute, Hosts: tt.hosts},
+				ClientOptions{AuthToken: "1234", Host: parsed.Host},
+				true,
+			)
+			if err != nil {
+				t.Fatalf("Failed to create GraphQL client: %v", err)
+			}
+
+			_, _, err = client.GetReposPulls(context.TODO(), []string{"octocat/hello-world"}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("(*GraphQLClient).GetReposPulls() failed: got error %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
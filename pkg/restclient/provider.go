@@ -0,0 +1,125 @@
+package restclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
+	resources "github.com/sarumaj/gh-gr/v2/pkg/restclient/resources"
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
+)
+
+// Provider represents the API of a forge hosting repositories (e.g. GitHub or Gitea).
+// Responses of all providers are converted into the resources of the GitHub API.
+type Provider interface {
+	ClosePullRequest(ctx context.Context, owner, repo string, number int) error
+	GetAllUserRepos(ctx context.Context, include, exclude []string) ([]resources.Repository, error)
+	GetInstallationRepos(ctx context.Context) ([]resources.Repository, error)
+	GetOrgRepoPulls(ctx context.Context, name, repo string, filter map[string]string) ([]resources.PullRequest, error)
+	GetOrgRepos(ctx context.Context, name string) ([]resources.Repository, error)
+	GetUser(ctx context.Context) (*resources.User, error)
+	GetUserByLogin(ctx context.Context, login string) (*resources.User, error)
+	GetUserOrgs(ctx context.Context) ([]resources.Organization, error)
+	GetUserRepos(ctx context.Context) ([]resources.Repository, error)
+	ReopenPullRequest(ctx context.Context, owner, repo string, number int) error
+	SearchOrgRepoPulls(ctx context.Context, name, repo string, filter string) ([]resources.PullRequest, error)
+}
+
+// getAllUserRepos retrieves all repositories of the user and of the organizations the user belongs to.
+// Organizations, whose repositories cannot match the include and exclude patterns, are skipped.
+func getAllUserRepos(ctx context.Context, provider Provider, bar *util.Progressbar, include, exclude []string) ([]resources.Repository, error) {
+	repos, err := provider.GetUserRepos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs, err := provider.GetUserOrgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(1<<63 - 1)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	for _, org := range orgs {
+		bar.Describe("Retrieving repositories for organization: %s...", org.Login)
+
+		switch includes, excludes := util.PatternList(include), util.PatternList(exclude); {
+		case
+			len(include) > 0 && !(includes.RegexMatch(org.Login+"/someRepository", timeout) || includes.RegexMatch(org.Login+"/", timeout)),
+			len(exclude) > 0 && (excludes.RegexMatch(org.Login+"/someRepository", timeout) || excludes.RegexMatch(org.Login+"/", timeout)):
+
+			continue
+		}
+
+		orgRepos, err := provider.GetOrgRepos(ctx, org.Login)
+		if err != nil {
+			return nil, err
+		}
+
+		repos = append(repos, orgRepos...)
+
+	}
+
+	return repos, nil
+}
+
+// Create new API client of given provider (see configfile.Profile.GetProvider).
+// The rate limit of the API will be checked upfront for providers supporting it, unless exhausted quotas should be waited for (retry).
+func NewProvider(conf *configfile.Configuration, provider string, options ClientOptions, retry bool) (Provider, error) {
+	switch provider {
+
+	case configfile.ProviderGitHub, "":
+		client, err := NewRESTClient(conf, options, retry)
+		if err != nil {
+			return nil, err
+		}
+
+		return client, nil
+
+	case configfile.ProviderGitea:
+		client, err := NewGiteaClient(conf, options, retry)
+		if err != nil {
+			return nil, err
+		}
+
+		return client, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported provider: %q", provider)
+
+	}
+}
+
+// prepareClientOptions completes the options of a new API client.
+// If no token is provided, it is retrieved from the token sources configured for the first profile of the host.
+// If no transport is provided, the network settings (proxy, CA bundle, client certificate) of configured hosts are applied.
+// Requests are sent through a transport chain of the client applying rate limiting and response caching,
+// whose waiting time is displayed on given progressbar. Only the response cache is shared between clients.
+func prepareClientOptions(conf *configfile.Configuration, options ClientOptions, retry bool, bar *util.Progressbar) (ClientOptions, error) {
+	if options.AuthToken == "" && conf != nil {
+		if profile, ok := conf.Profiles.ForHost(options.Host); ok {
+			options.AuthToken, _ = conf.GetToken(profile)
+		}
+	}
+
+	if options.Transport == nil && conf != nil {
+		transport, err := conf.Hosts.Transport()
+		if err != nil {
+			return options, err
+		}
+
+		options.Transport = transport
+	}
+
+	throttled := newThrottledTransport()
+	throttled.SetRetry(retry)
+	throttled.SetTransport(options.Transport)
+	throttled.SetProgressbar(bar)
+	options.Transport = defaultCachedTransport.withTransport(throttled)
+
+	return options, nil
+}
@@ -0,0 +1,6 @@
+package resources
+
+type InstallationRepositories struct {
+	TotalCount   int          `json:"total_count"`
+	Repositories []Repository `json:"repositories"`
+}
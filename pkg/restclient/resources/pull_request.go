@@ -41,4 +41,6 @@ type PullRequest struct {
 	AuthorAssociation  string           `json:"author_association"`
 	AutoMerge          interface{}      `json:"auto_merge"`
 	Draft              bool             `json:"draft"`
+	ReviewDecision     string           `json:"review_decision"` // This field is not present in the GitHub API response.
+	CheckStatus        string           `json:"check_status"`    // This field is not present in the GitHub API response.
 }
@@ -3,11 +3,12 @@ package restclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"strings"
-	"time"
 
 	api "github.com/cli/go-gh/v2/pkg/api"
 	configfile "github.com/sarumaj/gh-gr/v2/pkg/configfile"
@@ -15,6 +16,9 @@ import (
 	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 )
 
+// Regular expression used to extract the repository (<owner>/<name>) from the API URL of an issue.
+var issueURLRegex = regexp.MustCompile(`/repos/([^/]+/[^/]+)/issues/\d+$`)
+
 // Module logger.
 var loggerEntry = util.Logger.WithField("mod", "restclient")
 
@@ -26,6 +30,7 @@ type RESTClient struct {
 	*api.RESTClient
 	*configfile.Configuration
 	*util.Progressbar
+	rate *resources.RateLimit
 }
 
 // Close a pull request.
@@ -52,42 +57,27 @@ func (c *RESTClient) DoWithContext(ctx context.Context, method string, path stri
 
 // Get all repositories for given user and the organizations he belongs to.
 func (c *RESTClient) GetAllUserRepos(ctx context.Context, include, exclude []string) ([]resources.Repository, error) {
-	repos, err := c.GetUserRepos(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	orgs, err := c.GetUserOrgs(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	timeout := time.Duration(1<<63 - 1)
-	if deadline, ok := ctx.Deadline(); ok {
-		timeout = time.Until(deadline)
-	}
-
-	for _, org := range orgs {
-		c.ProgressBar.Describe(fmt.Sprintf("Retrieving repositories for GitHub organization: %s...", org.Login))
+	return getAllUserRepos(ctx, c, c.Progressbar, include, exclude)
+}
 
-		switch includes, excludes := util.PatternList(include), util.PatternList(exclude); {
-		case
-			len(include) > 0 && !(includes.RegexMatch(org.Login+"/someRepository", timeout) || includes.RegexMatch(org.Login+"/", timeout)),
-			len(exclude) > 0 && (excludes.RegexMatch(org.Login+"/someRepository", timeout) || excludes.RegexMatch(org.Login+"/", timeout)):
+// Get all repositories accessible to the GitHub App installation the client is authenticated as.
+func (c *RESTClient) GetInstallationRepos(ctx context.Context) (repos []resources.Repository, err error) {
+	c.Progressbar.Describe("Retrieving repositories for GitHub App installation...")
+	for page := 1; ; page++ {
+		var paged resources.InstallationRepositories
+		if err := c.DoWithContext(ctx, http.MethodGet, newRequestPath(installReposEp).
+			Add("per_page", "100").
+			Add("page", fmt.Sprintf("%d", page)).
+			String(), nil, &paged); err != nil {
 
-			continue
-		}
-
-		orgRepos, err := c.GetOrgRepos(ctx, org.Login)
-		if err != nil {
 			return nil, err
 		}
 
-		repos = append(repos, orgRepos...)
-
+		repos = append(repos, paged.Repositories...)
+		if len(paged.Repositories) == 0 || len(repos) >= paged.TotalCount {
+			return repos, nil
+		}
 	}
-
-	return repos, nil
 }
 
 // Get an organization.
@@ -122,6 +112,9 @@ func (c *RESTClient) GetRateLimit(ctx context.Context) (rate *resources.RateLimi
 	return rate, resp.Header, nil
 }
 
+// RateLimit returns the rate limit retrieved upon creation of the client.
+func (c *RESTClient) RateLimit() *resources.RateLimit { return c.rate }
+
 // Get all pull requests for given organization and repository.
 func (c *RESTClient) GetOrgRepoPulls(ctx context.Context, name, repo string, filter map[string]string) (out []resources.PullRequest, err error) {
 	c.Describe("Retrieving pull requests for GitHub repository: %s/%s...", name, repo)
@@ -163,6 +156,12 @@ func (c *RESTClient) GetUser(ctx context.Context) (user *resources.User, err err
 	return
 }
 
+// Get GitHub user by login.
+func (c *RESTClient) GetUserByLogin(ctx context.Context, login string) (user *resources.User, err error) {
+	err = c.DoWithContext(ctx, http.MethodGet, newRequestPath(usersEp.Format(map[string]any{"username": login})).String(), nil, &user)
+	return
+}
+
 // Get all repositories for given user.
 func (c *RESTClient) GetUserRepos(ctx context.Context) ([]resources.Repository, error) {
 	c.Progressbar.Describe("Retrieving repositories for current user...")
@@ -216,14 +215,76 @@ func (c *RESTClient) SearchOrgRepoPulls(ctx context.Context, name, repo string,
 	return out, nil
 }
 
+// Search for pull requests in given repositories (<owner>/<name>).
+// Repositories are grouped by owner into combined search queries (see buildSearchQueries), the owner is qualified with user:,
+// if it is given user. Pull requests are mapped back onto the repositories, errors are mapped to the repositories of failed queries.
+func (c *RESTClient) SearchReposPulls(ctx context.Context, user string, repos []string, filter string) (map[string][]resources.PullRequest, map[string]error) {
+	pulls := make(map[string][]resources.PullRequest)
+	errs := make(map[string]error)
+
+	for pending := buildSearchQueries(user, repos, filter); len(pending) > 0; {
+		query := pending[0]
+		pending = pending[1:]
+
+		c.Describe("Searching pull requests for %d GitHub repositories...", len(query.Repositories))
+		searchResults, err := getPaged[resources.PullRequest, resources.SearchResult[resources.PullRequest]](c, searchIssuesEp, ctx, func(rp *requestPath) {
+			rp.Set("q", query.Query)
+		})
+
+		var httpErr *api.HTTPError
+		switch {
+
+		// owner cannot be qualified as a whole, retry qualifying repositories individually
+		case errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnprocessableEntity && query.Owner != "":
+			pending = append(pending, query.split()...)
+			continue
+
+		case err != nil:
+			for _, repo := range query.Repositories {
+				errs[repo] = err
+			}
+			continue
+
+		// search results are capped, retry searching fewer repositories at once
+		case query.isCapped(searchResults.TotalCount, searchResults.IncompleteResults):
+			if split := query.split(); len(split) > 0 {
+				loggerEntry.Debugf("Search results of %q are capped (total: %d), splitting query", query.Query, searchResults.TotalCount)
+				pending = append(pending, split...)
+				continue
+			}
+
+			loggerEntry.Warnf("Search results of %q are capped (total: %d), some pull requests may be missing", query.Query, searchResults.TotalCount)
+
+		}
+
+		// search results of other repositories of the owner are discarded
+		for _, item := range searchResults.Items {
+			match := issueURLRegex.FindStringSubmatch(item.URL)
+			if len(match) < 2 {
+				continue
+			}
+
+			if repo, ok := query.mapSearchResult(match[1]); ok {
+				item.Repository = repo
+				pulls[repo] = append(pulls[repo], item)
+			}
+		}
+	}
+
+	return pulls, errs
+}
+
 // Create new REST API client.
-// The rate limit of the API will be checked upfront.
+// If no token is provided, it is retrieved from the token sources configured for the first profile of the host.
+// The rate limit of the API will be checked upfront, unless exhausted quotas should be waited for (retry).
 func NewRESTClient(conf *configfile.Configuration, options ClientOptions, retry bool) (*RESTClient, error) {
-	defaultTransport.SetRetry(retry)
-	defaultTransport.SetTransport(options.Transport)
-	options.Transport = defaultTransport
-	loggerEntry.Debugf("Creating client with options: %+v", options)
+	bar := util.NewProgressbar(-1)
+	options, err := prepareClientOptions(conf, options, retry, bar)
+	if err != nil {
+		return nil, err
+	}
 
+	loggerEntry.Debugf("Creating client with options: %+v", options)
 	client, err := api.NewRESTClient(options)
 	if err != nil {
 		return nil, err
@@ -232,7 +293,7 @@ func NewRESTClient(conf *configfile.Configuration, options ClientOptions, retry
 	wrapClient := &RESTClient{
 		RESTClient:    client,
 		Configuration: conf,
-		Progressbar:   util.NewProgressbar(-1),
+		Progressbar:   bar,
 	}
 
 	rate, _, err := wrapClient.GetRateLimit(context.Background())
@@ -240,6 +301,12 @@ func NewRESTClient(conf *configfile.Configuration, options ClientOptions, retry
 		return nil, err
 	}
 
-	defer CheckRateLimitAndExit(rate)
+	wrapClient.rate = rate
+
+	// when retrying, requests wait until exhausted quotas are reset
+	if !retry {
+		defer CheckRateLimitAndExit(rate)
+	}
+
 	return wrapClient, nil
 }
@@ -3,10 +3,17 @@ package restclient
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -35,6 +42,9 @@ func setupTestClient(tb testing.TB, server *httptest.Server) *RESTClient {
 		tb.Fatalf("Failed to parse server URL: %v", err)
 	}
 
+	// keep cached responses out of the config directory
+	defaultCachedTransport.Directory = tb.TempDir()
+
 	client, err := NewRESTClient(
 		&configfile.Configuration{Concurrency: 16, Timeout: time.Hour},
 		ClientOptions{
@@ -71,6 +81,14 @@ func TestRESTClient(t *testing.T) {
 		}
 	})
 
+	t.Run("GetInstallationRepos", func(t *testing.T) {
+		if repos, err := client.GetInstallationRepos(context.TODO()); err != nil {
+			t.Fatalf("Failed to get installation repos: %v", err)
+		} else if len(repos) == 0 {
+			t.Fatalf("Failed to get installation repos: no repositories found")
+		}
+	})
+
 	t.Run("GetOrgRepos", func(t *testing.T) {
 		if repos, err := client.GetOrgRepos(context.TODO(), "github"); err != nil {
 			t.Fatalf("Failed to get org repos: %v", err)
@@ -135,6 +153,14 @@ func TestRESTClient(t *testing.T) {
 		}
 	})
 
+	t.Run("GetUserByLogin", func(t *testing.T) {
+		if user, err := client.GetUserByLogin(context.TODO(), "octocat"); err != nil {
+			t.Fatalf("Failed to get user by login: %v", err)
+		} else if user == nil || user.Login != "octocat" {
+			t.Fatalf("Failed to get user by login: no user found")
+		}
+	})
+
 	t.Run("GetUserRepos", func(t *testing.T) {
 		if repos, err := client.GetUserRepos(context.TODO()); err != nil {
 			t.Fatalf("Failed to get user repos: %v", err)
@@ -166,3 +192,116 @@ func TestRESTClient(t *testing.T) {
 		}
 	})
 }
+
+func TestRESTClientSearchReposPullsCapped(t *testing.T) {
+	repoRegex := regexp.MustCompile(`repo:(\S+)`)
+
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/search/issues" {
+			http.ServeFile(w, r, filepath.Join("test", r.URL.Path+".json"))
+			return
+		}
+
+		query := r.URL.Query().Get("q")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		// results of owners are capped, results of individual repositories are complete
+		result := map[string]any{"total_count": 1500, "incomplete_results": false, "items": []any{}}
+		if !strings.Contains(query, "org:") {
+			var items []any
+			for _, match := range repoRegex.FindAllStringSubmatch(query, -1) {
+				items = append(items, map[string]any{"url": fmt.Sprintf("https://%s/api/v3/repos/%s/issues/1", r.Host, match[1])})
+			}
+
+			result = map[string]any{"total_count": len(items), "incomplete_results": false, "items": items}
+		}
+
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+	server.TLS = &tls.Config{}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	client := setupTestClient(t, server)
+
+	var repos []string
+	for i := range 20 {
+		repos = append(repos, fmt.Sprintf("someorg/repository-%d", i))
+	}
+
+	pulls, errs := client.SearchReposPulls(context.TODO(), "octocat", repos, "")
+	if len(errs) != 0 {
+		t.Fatalf("Failed to search repos pulls: %v", errs)
+	}
+
+	for _, repo := range repos {
+		if len(pulls[repo]) != 1 || pulls[repo][0].Repository != repo {
+			t.Errorf("Unexpected pull requests of %s: %+v", repo, pulls[repo])
+		}
+	}
+
+	if len(queries) < 2 || !strings.Contains(queries[0], "org:someorg") || slices.ContainsFunc(queries[1:], func(q string) bool { return strings.Contains(q, "org:") }) {
+		t.Errorf("Capped query not split into repository qualifiers: %q", queries)
+	}
+}
+
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	http.RoundTripper
+	requests atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func TestNewRESTClientConcurrently(t *testing.T) {
+	server := setupTestServer(t)
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+
+	defaultCachedTransport.Directory = t.TempDir()
+
+	// every client sends its requests through its own transport
+	transports := make([]*countingTransport, 8)
+	var wg sync.WaitGroup
+	for i := range transports {
+		transports[i] = &countingTransport{RoundTripper: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			client, err := NewRESTClient(
+				&configfile.Configuration{Concurrency: 1, Timeout: time.Minute},
+				ClientOptions{AuthToken: fmt.Sprint(i), Host: parsed.Host, Transport: transports[i]},
+				i%2 == 0,
+			)
+			if err != nil {
+				t.Errorf("Failed to create REST client: %v", err)
+				return
+			}
+
+			for range i {
+				if _, err := client.GetUser(context.TODO()); err != nil {
+					t.Errorf("Failed to get user: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the rate limit is requested upon creation of the client
+	for i, transport := range transports {
+		if got, want := transport.requests.Load(), int64(i+1); got != want {
+			t.Errorf("Unexpected requests of client %d: got %d, want %d", i, got, want)
+		}
+	}
+}
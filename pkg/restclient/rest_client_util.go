@@ -25,6 +25,8 @@ func consolidate[T any, R interface {
 		s, sOK := any(source).(resources.SearchResult[T])
 		t, tOK := any(target).(resources.SearchResult[T])
 		if sOK && tOK {
+			t.TotalCount = max(t.TotalCount, s.TotalCount)
+			t.IncompleteResults = t.IncompleteResults || s.IncompleteResults
 			t.Items = append(t.Items, s.Items...)
 			target = any(t).(R)
 			return target
@@ -109,7 +111,7 @@ func getPaged[T any, R interface {
 		_ = c.ChangeMax(last)
 		for page := 2; page <= last; page++ {
 			util.Logger.Debugf("Dispatching request for page %d", page)
-			batch.Queue(getPagedWorkUnit[T, R](c, ep, ctx, page))
+			batch.Queue(getPagedWorkUnit[T, R](c, ep, ctx, page, options...))
 		}
 
 		batch.QueueComplete()
@@ -130,18 +132,22 @@ func getPaged[T any, R interface {
 // Worker to send paginated requests.
 func getPagedWorkUnit[T any, R interface {
 	[]T | resources.SearchResult[T]
-}](c *RESTClient, ep apiEndpoint, ctx context.Context, page int) pool.WorkFunc {
+}](c *RESTClient, ep apiEndpoint, ctx context.Context, page int, options ...func(*requestPath)) pool.WorkFunc {
 	return func(wu pool.WorkUnit) (any, error) {
 		defer c.Inc()
 
+		params := newRequestPath(ep).
+			Add("per_page", "100").
+			Add("page", fmt.Sprintf("%d", page))
+		for _, option := range options {
+			option(params)
+		}
+
 		var paged R
 		err := c.DoWithContext(
 			ctx,
 			http.MethodGet,
-			newRequestPath(ep).
-				Add("per_page", "100").
-				Add("page", fmt.Sprintf("%d", page)).
-				String(),
+			params.String(),
 			nil,
 			&paged,
 		)
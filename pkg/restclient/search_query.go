@@ -0,0 +1,121 @@
+package restclient
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Limits of search queries supported by GitHub.
+const (
+	searchQueryMaxLength   = 256  // Maximum length of a search query
+	searchResultsMaxLength = 1000 // Maximum number of results retrievable for a search query
+)
+
+// searchQuery is a search query for pull requests of multiple repositories.
+type searchQuery struct {
+	prefix       string
+	Query        string
+	Owner        string   // Owner qualified as a whole (org: or user:), empty if repositories are qualified individually (repo:)
+	Repositories []string // Repositories (<owner>/<name>) matched by the query
+}
+
+// buildSearchQueries groups repositories (<owner>/<name>) into as few search queries for pull requests as possible.
+// Repositories are qualified individually (repo:) and packed into queries not exceeding the maximum query length.
+// If the repositories of an owner do not fit into a single query, the owner is qualified as a whole instead,
+// i.e. with user: if the owner is given user, otherwise with org:.
+// Results of queries qualifying an owner as a whole have to be mapped back onto the repositories.
+func buildSearchQueries(user string, repos []string, filter string) []searchQuery {
+	prefix := "is:pr"
+	if filter != "" {
+		prefix += " " + filter
+	}
+
+	owners := make(map[string][]string)
+	for _, repo := range repos {
+		owner, _, _ := strings.Cut(repo, "/")
+		owners[owner] = append(owners[owner], repo)
+	}
+
+	var queries []searchQuery
+	var pooled []string
+	for _, owner := range slices.Sorted(maps.Keys(owners)) {
+		length := len(prefix)
+		for _, repo := range owners[owner] {
+			length += len(" repo:") + len(repo)
+		}
+
+		if length <= searchQueryMaxLength || len(owners[owner]) == 1 {
+			pooled = append(pooled, owners[owner]...)
+			continue
+		}
+
+		qualifier := "org:"
+		if strings.EqualFold(owner, user) {
+			qualifier = "user:"
+		}
+
+		queries = append(queries, searchQuery{
+			prefix:       prefix,
+			Query:        prefix + " " + qualifier + owner,
+			Owner:        owner,
+			Repositories: owners[owner],
+		})
+	}
+
+	return append(queries, packSearchQueries(prefix, pooled)...)
+}
+
+// packSearchQueries packs repositories (<owner>/<name>) into queries qualifying them individually (repo:).
+// Each query contains at least one repository, even if it exceeds the maximum query length.
+func packSearchQueries(prefix string, repos []string) []searchQuery {
+	var queries []searchQuery
+	for _, repo := range repos {
+		qualifier := " repo:" + repo
+		if n := len(queries); n > 0 && len(queries[n-1].Query)+len(qualifier) <= searchQueryMaxLength {
+			queries[n-1].Query += qualifier
+			queries[n-1].Repositories = append(queries[n-1].Repositories, repo)
+			continue
+		}
+
+		queries = append(queries, searchQuery{prefix: prefix, Query: prefix + qualifier, Repositories: []string{repo}})
+	}
+
+	return queries
+}
+
+// mapSearchResult maps the repository of a search result (<owner>/<name>) back onto the repositories matched by the query.
+// Repositories are compared case-insensitively, since the search API does not preserve the case of the query.
+func (q searchQuery) mapSearchResult(repository string) (string, bool) {
+	for _, repo := range q.Repositories {
+		if strings.EqualFold(repo, repository) {
+			return repo, true
+		}
+	}
+
+	return "", false
+}
+
+// isCapped checks whether the results of the query have been capped, i.e. not all matching pull requests have been retrieved.
+func (q searchQuery) isCapped(totalCount int, incompleteResults bool) bool {
+	return incompleteResults || totalCount > searchResultsMaxLength
+}
+
+// split qualifies the repositories of the query individually (repo:), e.g. if the owner cannot be qualified as a whole.
+// Queries qualifying repositories individually are split in halves, e.g. if their results are capped.
+// Queries of a single repository cannot be split.
+func (q searchQuery) split() []searchQuery {
+	switch {
+
+	case q.Owner != "":
+		return packSearchQueries(q.prefix, q.Repositories)
+
+	case len(q.Repositories) > 1:
+		half := len(q.Repositories) / 2
+		return append(packSearchQueries(q.prefix, q.Repositories[:half]), packSearchQueries(q.prefix, q.Repositories[half:])...)
+
+	default:
+		return nil
+
+	}
+}
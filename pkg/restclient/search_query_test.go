@@ -0,0 +1,134 @@
+package restclient
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildSearchQueries(t *testing.T) {
+	var many []string
+	for i := range 20 {
+		many = append(many, fmt.Sprintf("someorg/repository-%d", i))
+	}
+
+	type args struct {
+		user   string
+		repos  []string
+		filter string
+	}
+
+	for _, tt := range []struct {
+		name string
+		args args
+		want []string
+	}{
+		{"test#1", args{"octocat", []string{"octocat/a", "hubot/b"}, ""},
+			[]string{"is:pr repo:hubot/b repo:octocat/a"}},
+		{"test#2", args{"octocat", []string{"octocat/a"}, "state:open"},
+			[]string{"is:pr state:open repo:octocat/a"}},
+		{"test#3", args{"octocat", many, "state:open"},
+			[]string{"is:pr state:open org:someorg"}},
+		{"test#4", args{"SomeOrg", append([]string{"octocat/a"}, many...), ""},
+			[]string{"is:pr user:someorg", "is:pr repo:octocat/a"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, query := range buildSearchQueries(tt.args.user, tt.args.repos, tt.args.filter) {
+				got = append(got, query.Query)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(`buildSearchQueries(%q, %v, %q) failed: got: %q, want: %q`, tt.args.user, tt.args.repos, tt.args.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQuerySplit(t *testing.T) {
+	var repos []string
+	for i := range 20 {
+		repos = append(repos, fmt.Sprintf("someorg/repository-%d", i))
+	}
+
+	queries := buildSearchQueries("octocat", repos, "")
+	if len(queries) != 1 || queries[0].Owner != "someorg" {
+		t.Fatalf(`buildSearchQueries() failed: got: %+v, want a single query qualifying "someorg"`, queries)
+	}
+
+	var got []string
+	for _, query := range queries[0].split() {
+		if len(query.Query) > searchQueryMaxLength || query.Owner != "" {
+			t.Errorf(`(searchQuery).split() failed: query %q exceeds maximum length or qualifies owner %q`, query.Query, query.Owner)
+		}
+
+		for _, repo := range query.Repositories {
+			if !strings.Contains(query.Query, " repo:"+repo) {
+				t.Errorf(`(searchQuery).split() failed: repository %q not qualified in %q`, repo, query.Query)
+			}
+		}
+
+		got = append(got, query.Repositories...)
+	}
+
+	if !reflect.DeepEqual(got, repos) {
+		t.Errorf(`(searchQuery).split() failed: got: %v, want: %v`, got, repos)
+	}
+
+	if repo, ok := queries[0].mapSearchResult("SomeOrg/Repository-3"); !ok || repo != repos[3] {
+		t.Errorf(`(searchQuery).mapSearchResult() failed: got: %q, want: %q`, repo, repos[3])
+	}
+
+	if _, ok := queries[0].mapSearchResult("someorg/other"); ok {
+		t.Errorf(`(searchQuery).mapSearchResult() failed: unconfigured repository mapped`)
+	}
+}
+
+func TestSearchQueryIsCapped(t *testing.T) {
+	for _, tt := range []struct {
+		name              string
+		totalCount        int
+		incompleteResults bool
+		want              bool
+	}{
+		{"test#1", 0, false, false},
+		{"test#2", searchResultsMaxLength, false, false},
+		{"test#3", searchResultsMaxLength + 1, false, true},
+		{"test#4", 10, true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (searchQuery{}).isCapped(tt.totalCount, tt.incompleteResults); got != tt.want {
+				t.Errorf(`(searchQuery).isCapped(%d, %t) failed: got: %t, want: %t`, tt.totalCount, tt.incompleteResults, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQuerySplitRepositories(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []string
+		want [][]string
+	}{
+		{"test#1", []string{"octocat/a"}, nil},
+		{"test#2", []string{"octocat/a", "octocat/b"}, [][]string{{"octocat/a"}, {"octocat/b"}}},
+		{"test#3", []string{"octocat/a", "octocat/b", "hubot/c"}, [][]string{{"octocat/a"}, {"octocat/b", "hubot/c"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := packSearchQueries("is:pr", tt.args)
+			if len(queries) != 1 {
+				t.Fatalf(`packSearchQueries() failed: got: %+v, want a single query`, queries)
+			}
+
+			var got [][]string
+			for _, query := range queries[0].split() {
+				got = append(got, query.Repositories)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(`(searchQuery).split() failed: got: %v, want: %v`, got, tt.want)
+			}
+		})
+	}
+}
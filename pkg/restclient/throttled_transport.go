@@ -1,36 +1,70 @@
 package restclient
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	util "github.com/sarumaj/gh-gr/v2/pkg/util"
 )
 
 // Constants for rate limiting.
 const (
-	primaryRateLimit   = 5000      // Maximum number of requests per hour
-	secondaryRateLimit = 100       // Maximum of 100 concurrent points
-	rateLimitWindow    = time.Hour // Time window for the primary rate limit
+	secondaryRateLimit      = 100                    // Maximum of 100 concurrent points
+	mutatingRequestInterval = time.Second            // Minimum interval between mutating requests
+	secondaryRetryAttempts  = 5                      // Maximum number of replays of requests hitting the secondary rate limit
+	secondaryRetryBaseDelay = 2 * time.Second        // Initial delay of the exponential backoff
+	secondaryRetryMaxDelay  = time.Minute            // Maximum delay of the exponential backoff
+	secondaryRateLimitHint  = "secondary rate limit" // Hint contained in the error messages of secondary rate limit responses
 )
 
-// Default transport with rate limiting applied.
-var defaultTransport = newThrottledTransport()
+// clock provides the current time and timers, so that waiting can be simulated.
+type clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+}
+
+// systemClock is the clock of the operating system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// rateLimitState holds the quota of a rate limited resource as reported by the server.
+type rateLimitState struct {
+	remaining int
+	reset     time.Time
+}
 
 // throttledTransport wraps an http.RoundTripper to throttle requests.
+// Quotas are tracked for each host and resource (e.g. "core" or "search") from the headers of the responses.
 type throttledTransport struct {
 	Transport    http.RoundTripper
-	requestTimes []time.Time
+	Progressbar  *util.Progressbar
 	points       int64
 	mu           sync.Mutex
-	rateReset    time.Time
+	rateLimits   map[string]rateLimitState
+	nextMutation time.Time
 	retry        bool
+	clock        clock
 }
 
 // calculatePointCost determines the cost of a request based on its HTTP method.
-func (t *throttledTransport) calculatePointCost(method string) int64 {
-	switch method {
+// GraphQL queries are sent using POST method, but cost as much as GET requests, unless they contain mutations.
+func (t *throttledTransport) calculatePointCost(req *http.Request) int64 {
+	if t.isGraphQLQuery(req) {
+		return 1
+	}
+
+	switch req.Method {
 	case http.MethodGet, http.MethodHead, http.MethodOptions:
 		return 1
 	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
@@ -40,63 +74,198 @@ func (t *throttledTransport) calculatePointCost(method string) int64 {
 	}
 }
 
-// handlePrimaryRateLimit enforces the primary rate limit window.
-func (t *throttledTransport) handlePrimaryRateLimit() {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+// isSecondaryRateLimit checks whether given response has been rejected due to the secondary rate limit (or abuse detection).
+// The body of the response is restored, so that it can be read by the caller.
+func (t *throttledTransport) isSecondaryRateLimit(resp *http.Response) bool {
+	switch resp.StatusCode {
 
-	now := time.Now()
-	cutoff := now.Add(-rateLimitWindow)
-	validRequests := 0
+	case http.StatusTooManyRequests, http.StatusForbidden:
+		// exhausted primary rate limit
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return false
+		}
 
-	for _, ts := range t.requestTimes {
-		if ts.After(cutoff) {
-			break
+		if resp.Header.Get("Retry-After") != "" {
+			return true
 		}
-		validRequests++
-	}
 
-	t.requestTimes = t.requestTimes[validRequests:]
+	default:
+		return false
+
+	}
 
-	if len(t.requestTimes) >= primaryRateLimit {
-		sleepDuration := t.requestTimes[0].Add(rateLimitWindow).Sub(now)
-		time.Sleep(sleepDuration)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
 	}
 
-	t.requestTimes = append(t.requestTimes, time.Now())
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, secondaryRateLimitHint) || strings.Contains(message, "abuse")
 }
 
-// shouldRetry determines if a request should be retried based on rate limit headers.
-func (t *throttledTransport) shouldRetry(req *http.Request) (time.Duration, error) {
-	remainingStr := req.Header.Get("X-RateLimit-Remaining")
-	if remainingStr == "" {
-		return 0, nil
+// isGraphQLQuery checks whether given request is a GraphQL query without mutations.
+func (t *throttledTransport) isGraphQLQuery(req *http.Request) bool {
+	if req.Method != http.MethodPost || req.GetBody == nil || !strings.HasSuffix(t.rateLimitKey(req), "/graphql") {
+		return false
 	}
 
-	remaining, err := strconv.Atoi(remainingStr)
+	body, err := req.GetBody()
 	if err != nil {
-		return 0, err
+		return false
+	}
+	defer body.Close()
+
+	var payload struct {
+		Query string `json:"query"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return false
+	}
+
+	return !strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+}
+
+// isReplayable checks whether given request can be sent again, i.e. its body can be restored.
+func (t *throttledTransport) isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rateLimitKey determines the rate limited resource a request is accounted to.
+func (t *throttledTransport) rateLimitKey(req *http.Request) string {
+	resource := "core"
+	switch path := strings.TrimPrefix(req.URL.Path, "/api/v3"); {
+
+	case strings.HasPrefix(path, "/search/code"):
+		resource = "code_search"
+
+	case strings.HasPrefix(path, "/search/"):
+		resource = "search"
+
+	case strings.HasPrefix(path, "/graphql"), strings.HasPrefix(path, "/api/graphql"):
+		resource = "graphql"
+
 	}
 
-	if remaining > 0 {
-		return 0, nil
+	return req.URL.Host + "/" + resource
+}
+
+// updateRateLimit stores the quota reported in the headers of given response.
+func (t *throttledTransport) updateRateLimit(key string, resp *http.Response) error {
+	remainingStr := resp.Header.Get("X-RateLimit-Remaining")
+	resetStr := resp.Header.Get("X-RateLimit-Reset")
+	if remainingStr == "" || resetStr == "" {
+		return nil
 	}
 
-	resetStr := req.Header.Get("X-RateLimit-Reset")
-	if resetStr == "" {
-		return 0, nil
+	remaining, err := strconv.Atoi(remainingStr)
+	if err != nil {
+		return err
 	}
 
-	reset, err := strconv.Atoi(resetStr)
+	reset, err := strconv.ParseInt(resetStr, 10, 64)
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	// the resource reported by the server takes precedence
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" {
+		host, _, _ := strings.Cut(key, "/")
+		key = host + "/" + resource
 	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.rateReset = time.Unix(int64(reset), 0).Add(time.Second)
-	return time.Until(t.rateReset), nil
+	t.rateLimits[key] = rateLimitState{remaining: remaining, reset: time.Unix(reset, 0)}
+	return nil
+}
+
+// waitForPrimaryRateLimit waits until the quota of the resource has been reset, if it is exhausted.
+// If retrying is disabled, an error is returned instead.
+func (t *throttledTransport) waitForPrimaryRateLimit(req *http.Request, key string) error {
+	t.mu.Lock()
+	state, ok := t.rateLimits[key]
+	retry, bar := t.retry, t.Progressbar
+	t.mu.Unlock()
+
+	if !ok || state.remaining > 0 || !t.clock.Now().Before(state.reset) {
+		return nil
+	}
+
+	if !retry {
+		return fmt.Errorf("rate limit for %s exceeded, quota resets at %s", key, state.reset.Format(time.DateTime))
+	}
+
+	// add a second to account for clock drift
+	reset := state.reset.Add(time.Second)
+	for remaining := reset.Sub(t.clock.Now()); remaining > 0; remaining = reset.Sub(t.clock.Now()) {
+		if bar != nil {
+			bar.Describe("Rate limit for %s exceeded, waiting %s...", key, remaining.Round(time.Second))
+		}
+
+		if err := t.sleep(req, min(remaining, time.Second)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retryDelay determines the delay before given request is replayed.
+// The delay requested by the server (Retry-After) is honoured,
+// otherwise an exponential backoff with jitter is applied.
+func (t *throttledTransport) retryDelay(resp *http.Response, attempt int) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return max(date.Sub(t.clock.Now()), 0)
+		}
+	}
+
+	delay := min(secondaryRetryBaseDelay<<attempt, secondaryRetryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// sleep waits for given duration unless the context of the request is done.
+func (t *throttledTransport) sleep(req *http.Request, duration time.Duration) error {
+	if err := req.Context().Err(); err != nil || duration <= 0 {
+		return err
+	}
+
+	select {
+
+	case <-req.Context().Done():
+		return req.Context().Err()
+
+	case <-t.clock.After(duration):
+		return nil
+
+	}
+}
+
+// waitForMutatingRequest spaces out mutating requests, as recommended to avoid the secondary rate limit.
+func (t *throttledTransport) waitForMutatingRequest(req *http.Request) error {
+	if t.calculatePointCost(req) <= 1 {
+		return nil
+	}
+
+	t.mu.Lock()
+	now := t.clock.Now()
+	slot := now
+	if slot.Before(t.nextMutation) {
+		slot = t.nextMutation
+	}
+
+	t.nextMutation = slot.Add(mutatingRequestInterval)
+	t.mu.Unlock()
+
+	return t.sleep(req, slot.Sub(now))
 }
 
 // waitForSecondaryRateLimit waits until the secondary rate limit allows new requests.
@@ -113,26 +282,87 @@ func (t *throttledTransport) waitForSecondaryRateLimit(pointCost int64) {
 }
 
 // RoundTrip implements the http.RoundTripper interface, managing request throttling.
+// Requests rejected due to the secondary rate limit are replayed, if possible.
 func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	if t.retry {
-		waitDuration, err := t.shouldRetry(req)
-		if err != nil {
+	key := t.rateLimitKey(req)
+	for attempt := 0; ; attempt++ {
+		resp, err := t.roundTrip(key, req)
+		if err != nil || !t.isSecondaryRateLimit(resp) {
+			return resp, err
+		}
+
+		if attempt >= secondaryRetryAttempts || !t.isReplayable(req) {
+			loggerEntry.Debugf("Secondary rate limit exceeded for %s %s, giving up after %d attempts", req.Method, req.URL, attempt+1)
+			return resp, nil
+		}
+
+		delay := t.retryDelay(resp, attempt)
+		loggerEntry.Debugf("Secondary rate limit exceeded for %s %s, retrying in %s", req.Method, req.URL, delay)
+		_ = resp.Body.Close()
+
+		t.mu.Lock()
+		bar := t.Progressbar
+		t.mu.Unlock()
+
+		if bar != nil {
+			bar.Describe("Secondary rate limit for %s exceeded, waiting %s...", key, delay.Round(time.Second))
+		}
+
+		if err := t.sleep(req, delay); err != nil {
 			return nil, err
 		}
 
-		if waitDuration > 0 {
-			time.Sleep(waitDuration)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
 		}
 	}
+}
+
+// roundTrip sends a single request, while obeying the rate limits.
+func (t *throttledTransport) roundTrip(key string, req *http.Request) (*http.Response, error) {
+	// requests of the rate limit itself do not count against the quota
+	if !strings.HasSuffix(req.URL.Path, "/"+string(rateLimitEp)) {
+		if err := t.waitForPrimaryRateLimit(req, key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.waitForMutatingRequest(req); err != nil {
+		return nil, err
+	}
 
-	pointCost := t.calculatePointCost(req.Method)
+	pointCost := t.calculatePointCost(req)
 	t.waitForSecondaryRateLimit(pointCost)
 	atomic.AddInt64(&t.points, pointCost)
-	t.handlePrimaryRateLimit()
-	resp, err := t.Transport.RoundTrip(req)
+	t.mu.Lock()
+	transport := t.Transport
+	t.mu.Unlock()
+
+	resp, err := transport.RoundTrip(req)
 	atomic.AddInt64(&t.points, -pointCost)
 
-	return resp, err
+	if err != nil {
+		return resp, err
+	}
+
+	if err := t.updateRateLimit(key, resp); err != nil {
+		loggerEntry.Debugf("Failed to parse rate limit headers: %v", err)
+	}
+
+	return resp, nil
+}
+
+// SetProgressbar sets the progressbar used to display the time remaining until the quota is reset.
+func (t *throttledTransport) SetProgressbar(bar *util.Progressbar) {
+	t.mu.Lock()
+	t.Progressbar = bar
+	t.mu.Unlock()
 }
 
 // SetRetry sets the retry flag to enable or disable retrying.
@@ -156,7 +386,9 @@ func (t *throttledTransport) SetTransport(rt http.RoundTripper) {
 // newThrottledTransport creates a new ThrottledTransport with a default transport.
 func newThrottledTransport() *throttledTransport {
 	return &throttledTransport{
-		Transport: http.DefaultTransport,
-		points:    0,
+		Transport:  http.DefaultTransport,
+		points:     0,
+		rateLimits: make(map[string]rateLimitState),
+		clock:      systemClock{},
 	}
 }
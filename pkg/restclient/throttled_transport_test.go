@@ -0,0 +1,281 @@
+package restclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottledTransportRateLimitKey(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{"test#1", "https://api.github.com/repos/octocat/hello-world", "api.github.com/core"},
+		{"test#2", "https://api.github.com/search/issues?q=is:pr", "api.github.com/search"},
+		{"test#3", "https://api.github.com/search/code?q=test", "api.github.com/code_search"},
+		{"test#4", "https://api.github.com/graphql", "api.github.com/graphql"},
+		{"test#5", "https://example.com/api/v3/search/issues", "example.com/search"},
+		{"test#6", "https://example.com/api/graphql", "example.com/graphql"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.arg, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := newThrottledTransport().rateLimitKey(req)
+			if got != tt.want {
+				t.Errorf(`(*throttledTransport).rateLimitKey() failed: got: %q, want %q`, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeClock passes the time instantly, whenever a timer is requested.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// newFakeThrottledTransport creates a throttled transport, which does not wait in real time.
+func newFakeThrottledTransport(clock *fakeClock) *throttledTransport {
+	transport := newThrottledTransport()
+	transport.clock = clock
+	return transport
+}
+
+func TestThrottledTransportPrimaryRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	reset := clock.Now().Add(2 * time.Second).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/search/") && clock.Now().Before(reset):
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Resource", "search")
+		case strings.HasPrefix(r.URL.Path, "/exhausted/") && clock.Now().Before(reset):
+			w.Header().Set("X-RateLimit-Remaining", "0")
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "10")
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	do := func(ctx context.Context, transport *throttledTransport, path string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	t.Run("NoRetry", func(t *testing.T) {
+		transport := newFakeThrottledTransport(clock)
+		if err := do(context.Background(), transport, "/search/issues"); err != nil {
+			t.Fatalf("first request failed: %v", err)
+		}
+
+		if err := do(context.Background(), transport, "/search/issues"); err == nil {
+			t.Error("request to exhausted resource succeeded, want error")
+		}
+
+		if err := do(context.Background(), transport, "/repos/octocat/hello-world"); err != nil {
+			t.Errorf("request to other resource failed: %v", err)
+		}
+	})
+
+	t.Run("RateLimitEndpoint", func(t *testing.T) {
+		transport := newFakeThrottledTransport(clock)
+		if err := do(context.Background(), transport, "/exhausted/core"); err != nil {
+			t.Fatalf("first request failed: %v", err)
+		}
+
+		if err := do(context.Background(), transport, "/repos/octocat/hello-world"); err == nil {
+			t.Error("request to exhausted resource succeeded, want error")
+		}
+
+		if err := do(context.Background(), transport, "/rate_limit"); err != nil {
+			t.Errorf("request to rate limit of exhausted resource failed: %v", err)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		transport := newFakeThrottledTransport(clock)
+		transport.SetRetry(true)
+		if err := do(context.Background(), transport, "/search/issues"); err != nil {
+			t.Fatalf("first request failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := do(ctx, transport, "/search/issues"); !errors.Is(err, context.Canceled) {
+			t.Errorf("canceled request to exhausted resource failed: got error: %v, want: %v", err, context.Canceled)
+		}
+
+		if !clock.Now().Before(reset) {
+			t.Errorf("canceled request to exhausted resource waited until reset at %s", reset.Format(time.DateTime))
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		transport := newFakeThrottledTransport(clock)
+		transport.SetRetry(true)
+		if err := do(context.Background(), transport, "/search/issues"); err != nil {
+			t.Fatalf("first request failed: %v", err)
+		}
+
+		if err := do(context.Background(), transport, "/search/issues"); err != nil {
+			t.Fatalf("second request failed: %v", err)
+		}
+
+		if clock.Now().Before(reset) {
+			t.Errorf("request to exhausted resource did not wait until reset at %s", reset.Format(time.DateTime))
+		}
+	})
+}
+
+func TestThrottledTransportRetryDelay(t *testing.T) {
+	transport := newThrottledTransport()
+	for _, tt := range []struct {
+		name     string
+		header   string
+		attempt  int
+		min, max time.Duration
+	}{
+		{"test#1", "3", 0, 3 * time.Second, 3 * time.Second},
+		{"test#2", "", 0, secondaryRetryBaseDelay / 2, secondaryRetryBaseDelay},
+		{"test#3", "", 2, 2 * secondaryRetryBaseDelay, 4 * secondaryRetryBaseDelay},
+		{"test#4", "", 10, secondaryRetryMaxDelay / 2, secondaryRetryMaxDelay},
+		{"test#5", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			got := transport.retryDelay(resp, tt.attempt)
+			if got < tt.min || got > tt.max {
+				t.Errorf(`(*throttledTransport).retryDelay() failed: got: %s, want between %s and %s`, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestThrottledTransportSecondaryRateLimit(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); r.Method == http.MethodPatch && string(body) != `{"state":"closed"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch requests.Add(1) {
+
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+		case 2:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+
+		default:
+			_, _ = w.Write([]byte(`{}`))
+
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("Replay", func(t *testing.T) {
+		requests.Store(0)
+		req, err := http.NewRequest(http.MethodPatch, server.URL+"/repos/octocat/hello-world/pulls/1", strings.NewReader(`{"state":"closed"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := newFakeThrottledTransport(&fakeClock{now: time.Now()}).RoundTrip(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || requests.Load() != 3 {
+			t.Errorf("request not replayed: got status %d after %d requests, want %d after 3 requests", resp.StatusCode, requests.Load(), http.StatusOK)
+		}
+	})
+
+	t.Run("NotReplayable", func(t *testing.T) {
+		requests.Store(0)
+		req, err := http.NewRequest(http.MethodPatch, server.URL+"/repos/octocat/hello-world/pulls/1", io.NopCloser(strings.NewReader(`{"state":"closed"}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := newFakeThrottledTransport(&fakeClock{now: time.Now()}).RoundTrip(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests || requests.Load() != 1 {
+			t.Errorf("request replayed: got status %d after %d requests, want %d after 1 request", resp.StatusCode, requests.Load(), http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("SpaceOutMutatingRequests", func(t *testing.T) {
+		requests.Store(2)
+		clock := &fakeClock{now: time.Now()}
+		transport, start := newFakeThrottledTransport(clock), clock.Now()
+		for range 3 {
+			req, err := http.NewRequest(http.MethodPatch, server.URL+"/repos/octocat/hello-world/pulls/1", strings.NewReader(`{"state":"closed"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			_ = resp.Body.Close()
+		}
+
+		if elapsed := clock.Now().Sub(start); elapsed < 2*mutatingRequestInterval {
+			t.Errorf("mutating requests not spaced out: 3 requests took %s, want at least %s", elapsed, 2*mutatingRequestInterval)
+		}
+	})
+}
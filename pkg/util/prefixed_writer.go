@@ -0,0 +1,76 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixedWriter prepends a prefix to each line written to the underlying writer.
+// Incomplete lines are buffered until terminated or flushed.
+// Writers sharing the same mutex never interleave their lines.
+type PrefixedWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix []byte
+	buffer []byte
+}
+
+// Flush writes buffered incomplete line (terminated by a newline).
+func (p *PrefixedWriter) Flush() error {
+	if len(p.buffer) == 0 {
+		return nil
+	}
+
+	p.buffer = append(p.buffer, '\n')
+	return p.writeLines()
+}
+
+// Write implements the io.Writer interface.
+func (p *PrefixedWriter) Write(b []byte) (int, error) {
+	p.buffer = append(p.buffer, b...)
+	if err := p.writeLines(); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// writeLines writes all complete lines from the buffer.
+func (p *PrefixedWriter) writeLines() error {
+	index := bytes.LastIndexByte(p.buffer, '\n')
+	if index < 0 {
+		return nil
+	}
+
+	var out []byte
+	for _, line := range bytes.SplitAfter(p.buffer[:index+1], []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+
+		out = append(append(out, p.prefix...), line...)
+	}
+
+	p.buffer = append(p.buffer[:0], p.buffer[index+1:]...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err := p.w.Write(out)
+	return err
+}
+
+// NewPrefixedWriter creates new prefixed writer.
+// If mutex is nil, a new one is allocated.
+func NewPrefixedWriter(w io.Writer, prefix string, mu *sync.Mutex) *PrefixedWriter {
+	if mu == nil {
+		mu = &sync.Mutex{}
+	}
+
+	return &PrefixedWriter{
+		mu:     mu,
+		w:      w,
+		prefix: []byte(prefix),
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrefixedWriter(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"test#1", []string{}, ""},
+		{"test#2", []string{"line\n"}, "[repo] line\n"},
+		{"test#3", []string{"li", "ne\nnext"}, "[repo] line\n[repo] next\n"},
+		{"test#4", []string{"a\nb\n\nc"}, "[repo] a\n[repo] b\n[repo] \n[repo] c\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := bytes.NewBuffer(nil)
+			w := NewPrefixedWriter(buffer, "[repo] ", nil)
+			for _, chunk := range tt.args {
+				if _, err := w.Write([]byte(chunk)); err != nil {
+					t.Fatalf(`(*PrefixedWriter).Write(%q) failed: %v`, chunk, err)
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatalf(`(*PrefixedWriter).Flush() failed: %v`, err)
+			}
+
+			if got := buffer.String(); got != tt.want {
+				t.Errorf(`PrefixedWriter(%q) failed: got: %q, want: %q`, tt.args, got, tt.want)
+			}
+		})
+	}
+}